@@ -2,159 +2,339 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/twiny/blockscan/pkg/chain"
-	"github.com/twiny/blockscan/pkg/utils"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
-// indexer
+// indexer starts the pipeline: fetchers pull batches of block ids
+// from jobs queue & fetch them, savers write fetched blocks to the
+// store, so slow RPC calls & store writes don't wait on each other.
 func (idx *Indexer) indexer() {
-	idx.wg.Add(idx.conf.Indexer.Workers)
+	idx.wg.Add(idx.conf.Indexer.Workers + idx.writers)
 
 	for i := 0; i < idx.conf.Indexer.Workers; i++ {
-		go func() {
-			defer idx.wg.Done()
-
-			for {
-				select {
-				case <-idx.ctx.Done():
-					return
-				case i := <-idx.jobs:
-					// scan
-					if err := idx.scan(i); err != nil {
-						idx.log.Println(err)
-						continue
-					}
-
-					// check if newer than current head block
-					if !idx.subscribed && i >= idx.head {
-						// subcribe
-						idx.subscribe()
-
-						idx.head = i
-
-						idx.subscribed = true
-					}
-
-					// log
-					idx.log.Printf("scanned block id %d", i)
-				}
-			}
-		}()
+		go idx.fetcher()
 	}
-}
-
-// Subscribe
-func (idx *Indexer) subscribe() {
-	sub, err := idx.client.SubscribeNewHead(context.Background(), idx.events)
-	if err != nil {
-		idx.log.Println(err)
 
-		idx.subscribed = false
-		return
+	for i := 0; i < idx.writers; i++ {
+		go idx.saver()
 	}
+}
 
-	idx.wg.Add(1)
-	go func() {
-		defer idx.wg.Done()
+// saver saves fetched blocks to the store.
+func (idx *Indexer) saver() {
+	defer idx.wg.Done()
 
-		//  log
-		idx.log.Println("subscribed to new block")
-		for {
-			select {
-			case err := <-sub.Err():
+	for {
+		select {
+		case <-idx.ctx.Done():
+			return
+		case f := <-idx.writes:
+			if err := idx.save(f); err != nil {
 				idx.log.Println(err)
-				idx.subscribed = false
-				return
-			case <-idx.ctx.Done():
-				return
-			case header := <-idx.events:
-				head := header.Number.Int64()
-				idx.jobs <- head
-
-				// update head
-				idx.head = head
+				continue
 			}
+
+			// log
+			idx.log.Printf("scanned block id %d", f.batch.Block.Number)
 		}
-	}()
+	}
 }
 
-// scan
+// save
+func (idx *Indexer) save(f *fetched) error {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	return idx.storeBlock(ctx, f)
+}
+
+// scan fetches & saves block id right away, outside of the pipeline.
 func (idx *Indexer) scan(id int64) error {
 	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
 	defer cancel()
 
 	// already scanned
-	if idx.store.HasScanned(ctx, id) {
+	if idx.store.HasScanned(ctx, id) && !idx.replaced(id) {
 		return fmt.Errorf("block %d already scanned", id)
 	}
 
-	// rate limit
-	idx.limiter.Take()
-
-	return idx.storeBlock(ctx, id)
-}
-
-// storeBlock
-func (idx *Indexer) storeBlock(ctx context.Context, id int64) error {
-	// get block
-	block, err := idx.client.BlockByNumber(ctx, big.NewInt(id))
+	fetched, err := idx.fetchBatch(ctx, []int64{id})
 	if err != nil {
 		return err
 	}
 
+	return idx.storeBlock(ctx, fetched[0])
+}
+
+// fetched a block & everything indexed from it, waiting to be saved.
+type fetched struct {
+	block *types.Block
+	batch *chain.BlockBatch // without the total difficulty, known once saved
+}
+
+// assemble block with the receipts of its txs, in order, into the rows
+// to save. Internal calls, contract code & token metadata are fetched
+// here too, the store is only read to skip tokens already known.
+func (idx *Indexer) assemble(ctx context.Context, block *types.Block, receipts []*types.Receipt) (*fetched, error) {
+	id := block.Number().Int64()
 	hash := block.Hash()
+	header := block.Header()
 
-	txCount, err := idx.client.TransactionCount(ctx, hash)
-	if err != nil {
-		return err
+	b := &chain.Block{
+		Number:     id,
+		Hash:       hash.Hex(),
+		ParentHash: header.ParentHash.Hex(),
+		Miner:      header.Coinbase.Hex(),
+		Timestamp:  time.Unix(int64(header.Time), 0),
+		GasLimit:   header.GasLimit,
+		GasUsed:    header.GasUsed,
+		Difficulty: header.Difficulty.String(),
+		ExtraData:  hexutil.Encode(header.Extra),
+		Size:       block.Size(),
+		StateRoot:  header.Root.Hex(),
+		TxCount:    uint(len(block.Transactions())),
 	}
 
-	b := &chain.Block{
-		Number:    id,
-		Hash:      hash.Hex(),
-		Timestamp: time.Unix(int64(block.Time()), 0),
-		TxCount:   txCount,
+	if header.BaseFee != nil {
+		b.BaseFee = header.BaseFee.String()
 	}
 
-	if err := idx.store.SaveBlock(ctx, b); err != nil {
-		return err
+	if header.WithdrawalsHash != nil {
+		b.WithdrawalsRoot = header.WithdrawalsHash.Hex()
 	}
 
-	//
-	// get chain id
-	chainid, err := idx.client.ChainID(ctx)
-	if err != nil {
-		return err
+	for _, w := range block.Withdrawals() {
+		b.Withdrawals = append(b.Withdrawals, &chain.Withdrawal{
+			Index:          w.Index,
+			BlockNumber:    id,
+			ValidatorIndex: w.Validator,
+			Address:        w.Address.Hex(),
+			Amount:         w.Amount,
+		})
 	}
 
+	var txs = make([]*chain.Tx, 0, len(block.Transactions()))
+
 	for order, tx := range block.Transactions() {
-		msg, err := tx.AsMessage(types.LatestSignerForChainID(chainid), nil)
+		sender, err := types.Sender(types.LatestSignerForChainID(idx.chainID), tx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		t := &chain.Tx{
 			BlockNumber: block.Number().Int64(),
 			Hash:        tx.Hash().Hex(),
-			From:        msg.From().Hex(),
-			To:          utils.AddrToHex(msg.To()), // case *common.Address == nil
-			Amount:      tx.Value().Int64(),
+			From:        sender.Hex(),
+			Amount:      tx.Value().String(),
 			Nonce:       tx.Nonce(),
 			Timestamp:   time.Unix(int64(block.Time()), 0), // Tx timestamp is same as blocl timestamp
 			Order:       order,
+			Type:        tx.Type(),
+			GasLimit:    tx.Gas(),
+			GasPrice:    tx.GasPrice().String(),
+			Input:       hexutil.Encode(tx.Data()),
+			AccessList:  accessList(tx),
+			Receipt:     toReceipt(block, tx, receipts[order]),
 		}
 
-		if err := idx.store.SaveTx(ctx, t); err != nil {
-			idx.log.Println("idx_store_save_tx", err)
-			continue
+		// empty on contract creation
+		if to := tx.To(); to != nil {
+			t.To = to.Hex()
 		}
+
+		if tx.Type() >= types.DynamicFeeTxType {
+			t.MaxFeePerGas = tx.GasFeeCap().String()
+			t.MaxPriorityFeePerGas = tx.GasTipCap().String()
+		}
+
+		if tx.Type() == types.BlobTxType {
+			t.MaxFeePerBlobGas = tx.BlobGasFeeCap().String()
+			for _, h := range tx.BlobHashes() {
+				t.BlobHashes = append(t.BlobHashes, h.Hex())
+			}
+		}
+
+		txs = append(txs, t)
+	}
+
+	// internal calls, when tracing is enabled
+	internal, err := idx.fetchInternalTxs(ctx, block, txs)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := tokenTransfers(txs)
+
+	return &fetched{
+		block: block,
+		batch: &chain.BlockBatch{
+			Block:          b,
+			Txs:            txs,
+			InternalTxs:    internal,
+			Contracts:      idx.fetchContracts(ctx, txs),
+			Tokens:         idx.fetchTokens(ctx, transfers),
+			TokenTransfers: transfers,
+			NFTTransfers:   nftTransfers(txs),
+			BalanceDeltas:  balanceDeltas(idx.chainID, block, txs, internal),
+		},
+	}, nil
+}
+
+// storeBlock checks f against the stored chain & saves it.
+func (idx *Indexer) storeBlock(ctx context.Context, f *fetched) error {
+	// detect chain reorganization
+	if err := idx.checkReorg(ctx, f.block); err != nil {
+		return err
+	}
+
+	td, err := idx.totalDifficulty(ctx, f.block)
+	if err != nil {
+		return err
+	}
+
+	f.batch.Block.TotalDifficulty = td
+
+	// block & children are saved at once,
+	// on error nothing is saved and the
+	// block is retried.
+	if err := idx.store.SaveBlockBatch(ctx, f.batch); err != nil {
+		return err
+	}
+
+	// saved before its parent, or its children were
+	if err := idx.fillTotalDifficulty(ctx, f.block, td); err != nil {
+		idx.log.Println("idx_total_difficulty", err)
 	}
 
 	return nil
 }
+
+// accessList of tx, nil for legacy transactions.
+func accessList(tx *types.Transaction) []*chain.AccessTuple {
+	var list []*chain.AccessTuple
+
+	for _, a := range tx.AccessList() {
+		keys := make([]string, 0, len(a.StorageKeys))
+		for _, k := range a.StorageKeys {
+			keys = append(keys, k.Hex())
+		}
+
+		list = append(list, &chain.AccessTuple{
+			Address:     a.Address.Hex(),
+			StorageKeys: keys,
+		})
+	}
+
+	return list
+}
+
+// totalDifficulty of block from the stored total difficulty of its
+// parent, empty when the parent is not indexed or another block.
+func (idx *Indexer) totalDifficulty(ctx context.Context, block *types.Block) (string, error) {
+	id := block.Number().Int64()
+	if id == 0 {
+		return block.Difficulty().String(), nil
+	}
+
+	hash, err := idx.store.GetBlockHash(ctx, id-1)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// stale parent, left to the reorg checks
+	if hash != block.ParentHash().Hex() {
+		return "", nil
+	}
+
+	parent, err := idx.store.GetTotalDifficulty(ctx, id-1)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if parent == "" {
+		return "", nil
+	}
+
+	td, ok := new(big.Int).SetString(parent, 10)
+	if !ok {
+		return "", fmt.Errorf("block %d: invalid total difficulty %q", id-1, parent)
+	}
+
+	return td.Add(td, block.Difficulty()).String(), nil
+}
+
+// fillTotalDifficulty of block once its parent's is stored, then of the
+// stored children saved without one, as long as each builds on the
+// previous one. Run after every save, so whichever of a block & its
+// parent is saved last fills the other in.
+func (idx *Indexer) fillTotalDifficulty(ctx context.Context, block *types.Block, td string) error {
+	idx.difficulty.Lock()
+	defer idx.difficulty.Unlock()
+
+	id := block.Number().Int64()
+
+	if td == "" {
+		var err error
+		if td, err = idx.totalDifficulty(ctx, block); err != nil || td == "" {
+			return err
+		}
+
+		if err := idx.store.SetTotalDifficulty(ctx, id, td); err != nil {
+			return err
+		}
+	}
+
+	total, ok := new(big.Int).SetString(td, 10)
+	if !ok {
+		return fmt.Errorf("block %d: invalid total difficulty %q", id, td)
+	}
+
+	hash := block.Hash().Hex()
+
+	for id := id + 1; ; id++ {
+		stored, err := idx.store.GetTotalDifficulty(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || stored != "" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		child, err := idx.store.GetBlock(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// stale child, left to the reorg checks
+		if child.ParentHash != hash {
+			return nil
+		}
+		hash = child.Hash
+
+		difficulty, ok := new(big.Int).SetString(child.Difficulty, 10)
+		if !ok {
+			return fmt.Errorf("block %d: invalid difficulty %q", id, child.Difficulty)
+		}
+
+		total.Add(total, difficulty)
+
+		if err := idx.store.SetTotalDifficulty(ctx, id, total.String()); err != nil {
+			return err
+		}
+	}
+}
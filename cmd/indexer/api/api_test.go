@@ -0,0 +1,1017 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/source"
+	"github.com/twiny/blockscan/pkg/source/simulated"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// TestScan
+func TestScan(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		from: {Balance: big.NewInt(1e18)},
+	})
+
+	ctx := context.Background()
+
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := types.SignTx(
+		types.NewTransaction(0, to, big.NewInt(1000), 21000, gasPrice, nil),
+		types.LatestSignerForChainID(chainID),
+		key,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	for _, id := range []int64{0, 1} {
+		if err := idx.scan(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := idx.scan(1); err == nil {
+		t.Error("expected already scanned error")
+	}
+
+	block, err := store.GetBlock(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.TxCount != 1 || len(block.Txs) != 1 || block.Txs[0] != tx.Hash().Hex() {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	header, err := backend.HeaderByNumber(ctx, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.ParentHash != header.ParentHash.Hex() ||
+		block.Miner != header.Coinbase.Hex() ||
+		block.GasUsed != 21000 ||
+		block.GasLimit != header.GasLimit ||
+		block.BaseFee != header.BaseFee.String() ||
+		block.StateRoot != header.Root.Hex() ||
+		block.WithdrawalsRoot != header.WithdrawalsHash.Hex() ||
+		block.TotalDifficulty == "" {
+		t.Errorf("unexpected block header %+v", block)
+	}
+
+	got, err := store.GetTx(ctx, tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.From != from.Hex() || got.To != to.Hex() || got.Amount != "1000" {
+		t.Errorf("unexpected tx %+v", got)
+	}
+
+	if got.Receipt == nil || got.Receipt.Status != types.ReceiptStatusSuccessful || got.Receipt.GasUsed != 21000 {
+		t.Errorf("unexpected receipt %+v", got.Receipt)
+	}
+}
+
+// TestReconcile
+func TestReconcile(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		from: {Balance: big.NewInt(1e18)},
+	})
+
+	ctx := context.Background()
+
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		tx, err := types.SignTx(
+			types.NewTransaction(nonce, to, big.NewInt(1000), 21000, gasPrice, nil),
+			types.LatestSignerForChainID(chainID),
+			key,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := backend.SendTransaction(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+	}
+
+	// blob gas is burned on top of the gas fee
+	blob := sendBlobTx(t, backend, key, 2, to)
+	backend.Commit()
+
+	for _, id := range []int64{1, 2, 3} {
+		if err := idx.scan(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balance, err := store.GetBalance(ctx, to.Hex(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance.Balance != "2001" {
+		t.Errorf("got balance %s, want 2001", balance.Balance)
+	}
+
+	got, err := store.GetTx(ctx, blob.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := got.Receipt; r == nil || r.BlobGasUsed != params.BlobTxBlobGasPerBlob || r.BlobGasPrice == "" {
+		t.Errorf("got receipt %+v, want blob gas used & price", r)
+	}
+
+	mismatches, err := reconcile(ctx, store, backend, 0, 3, []string{from.Hex(), to.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range mismatches {
+		t.Errorf("mismatch %s: derived %s, actual %s", m.Address, m.Derived, m.Actual)
+	}
+}
+
+// sendBlobTx sends a transfer of 1 wei carrying an empty blob.
+func sendBlobTx(t *testing.T, backend *simulated.Backend, key *ecdsa.PrivateKey, nonce uint64, to common.Address) *types.Transaction {
+	t.Helper()
+
+	ctx := context.Background()
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blob kzg4844.Blob
+
+	commitment, err := kzg4844.BlobToCommitment(&blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := kzg4844.ComputeBlobProof(&blob, commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sidecar := &types.BlobTxSidecar{
+		Blobs:       []kzg4844.Blob{blob},
+		Commitments: []kzg4844.Commitment{commitment},
+		Proofs:      []kzg4844.Proof{proof},
+	}
+
+	tip := big.NewInt(params.GWei)
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.BlobTx{
+		ChainID:    uint256.MustFromBig(chainID),
+		Nonce:      nonce,
+		GasTipCap:  uint256.MustFromBig(tip),
+		GasFeeCap:  uint256.MustFromBig(feeCap),
+		Gas:        21000,
+		To:         to,
+		Value:      uint256.NewInt(1),
+		BlobFeeCap: uint256.NewInt(params.GWei),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+// TestScanDynamicFeeTx
+func TestScanDynamicFeeTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		from: {Balance: big.NewInt(1e18)},
+	})
+
+	ctx := context.Background()
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tip := big.NewInt(params.GWei)
+	feeCap := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	slot := common.HexToHash("0x01")
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     0,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       30000,
+		To:        &to,
+		Value:     big.NewInt(1),
+		Data:      []byte{0xca, 0xfe},
+		AccessList: types.AccessList{
+			{Address: to, StorageKeys: []common.Hash{slot}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	if err := idx.scan(1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetTx(ctx, tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Type != types.DynamicFeeTxType ||
+		got.GasLimit != 30000 ||
+		got.MaxFeePerGas != feeCap.String() ||
+		got.MaxPriorityFeePerGas != tip.String() ||
+		got.Input != "0xcafe" {
+		t.Errorf("unexpected tx %+v", got)
+	}
+
+	if len(got.AccessList) != 1 ||
+		got.AccessList[0].Address != to.Hex() ||
+		len(got.AccessList[0].StorageKeys) != 1 ||
+		got.AccessList[0].StorageKeys[0] != slot.Hex() {
+		t.Errorf("unexpected access list %+v", got.AccessList)
+	}
+}
+
+// TestWithdrawalDeltas
+func TestWithdrawalDeltas(t *testing.T) {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: new(big.Int),
+	}).WithBody(types.Body{
+		Withdrawals: types.Withdrawals{
+			{Index: 0, Validator: 1, Address: to, Amount: 2},
+			{Index: 1, Validator: 2, Address: to, Amount: 3},
+		},
+	})
+
+	deltas := balanceDeltas(big.NewInt(1), block, nil, nil)
+
+	if len(deltas) != 1 || deltas[0].Address != to.Hex() || deltas[0].Delta != "5000000000" {
+		t.Errorf("unexpected deltas %+v", deltas)
+	}
+}
+
+// TestInternalTransferDeltas
+func TestInternalTransferDeltas(t *testing.T) {
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: new(big.Int),
+	})
+
+	txs := []*chain.Tx{
+		{Hash: "0x1", BlockNumber: 1, From: "0xa0", To: "0xb0", Amount: "0"},
+		{Hash: "0x2", BlockNumber: 1, From: "0xa0", To: "0xb0", Amount: "0", Receipt: &chain.Receipt{Status: 0}},
+	}
+
+	internal := []*chain.InternalTx{
+		{TxHash: "0x1", Index: 0, Depth: 1, Type: "CALL", From: "0xb0", To: "0xc0", Value: "10", Error: "execution reverted"},
+		{TxHash: "0x1", Index: 1, Depth: 2, Type: "CALL", From: "0xc0", To: "0xd0", Value: "3"},
+		{TxHash: "0x1", Index: 2, Depth: 1, Type: "CALL", From: "0xb0", To: "0xe0", Value: "4"},
+		{TxHash: "0x1", Index: 3, Depth: 2, Type: "DELEGATECALL", From: "0xe0", To: "0xf0", Value: "4"},
+		{TxHash: "0x1", Index: 4, Depth: 2, Type: "CALL", From: "0xe0", To: "0xd0", Value: "1"},
+		{TxHash: "0x1", Index: 5, Depth: 1, Type: "SELFDESTRUCT", From: "0xb0", To: "0xa0", Value: "6"},
+		{TxHash: "0x2", Index: 0, Depth: 1, Type: "CALL", From: "0xb0", To: "0xc0", Value: "7"},
+	}
+
+	var got = map[string]string{}
+	for _, d := range balanceDeltas(big.NewInt(1), block, txs, internal) {
+		got[d.Address] = d.Delta
+	}
+
+	want := map[string]string{"0xa0": "6", "0xb0": "-10", "0xc0": "0", "0xd0": "1", "0xe0": "3", "0xf0": "0"}
+	for address, delta := range want {
+		if got[address] != delta && !(delta == "0" && got[address] == "") {
+			t.Errorf("%s: got delta %q, want %q", address, got[address], delta)
+		}
+	}
+}
+
+// TestPreByzantiumReceipt
+func TestPreByzantiumReceipt(t *testing.T) {
+	idx, _, _ := newIdleIndexer(t, 0)
+
+	var receipt types.Receipt
+	if err := json.Unmarshal([]byte(`{
+		"root": "0x00000000000000000000000000000000000000000000000000000000000000aa",
+		"cumulativeGasUsed": "0xcf08", "logsBloom": "0x`+fmt.Sprintf("%0512x", 0)+`", "logs": [],
+		"transactionHash": "0x00000000000000000000000000000000000000000000000000000000000000bb",
+		"contractAddress": "0x00000000000000000000000000000000000000cc",
+		"gasUsed": "0xcf08", "effectiveGasPrice": "0x1", "transactionIndex": "0x0"
+	}`), &receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	block := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(1),
+	})
+	tx := types.NewContractCreation(0, big.NewInt(5), 53000, big.NewInt(1), []byte{0x00})
+
+	r := toReceipt(block, tx, &receipt)
+	if r.Status != 0 || r.PostState != "0x00000000000000000000000000000000000000000000000000000000000000aa" || !succeeded(r) {
+		t.Fatalf("unexpected receipt %+v", r)
+	}
+
+	txs := []*chain.Tx{{Hash: tx.Hash().Hex(), BlockNumber: 1, From: "0xa", Amount: "5", Receipt: r}}
+
+	contracts := idx.fetchContracts(context.Background(), txs)
+	if len(contracts) != 1 || contracts[0].Address != common.HexToAddress("0xcc").Hex() {
+		t.Errorf("unexpected contracts %+v", contracts)
+	}
+
+	var credited bool
+	for _, d := range balanceDeltas(big.NewInt(1337), block, txs, nil) {
+		if d.Address == contracts[0].Address && d.Delta == "5" {
+			credited = true
+		}
+	}
+
+	if !credited {
+		t.Errorf("contract not credited with the value of its creation tx")
+	}
+}
+
+// TestFlattenCalls
+func TestFlattenCalls(t *testing.T) {
+	var root source.CallFrame
+	if err := json.Unmarshal([]byte(`{
+		"type": "CALL", "from": "0x00000000000000000000000000000000000000a1", "to": "0x00000000000000000000000000000000000000b1", "value": "0x0",
+		"calls": [
+			{
+				"type": "CALL", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000c1", "value": "0x10",
+				"calls": [
+					{"type": "STATICCALL", "from": "0x00000000000000000000000000000000000000c1", "to": "0x00000000000000000000000000000000000000d1"}
+				]
+			},
+			{"type": "CREATE", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000e1", "value": "0x1", "error": "execution reverted"}
+		]
+	}`), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := flattenCalls(&chain.Tx{Hash: "0xt", BlockNumber: 1}, &root)
+
+	var got = []string{}
+	for _, c := range calls {
+		got = append(got, fmt.Sprintf("%d:%d:%s:%s:%s", c.Index, c.Depth, c.Type, c.Value, c.Error))
+	}
+
+	want := []string{
+		"0:1:CALL:16:",
+		"1:2:STATICCALL:0:",
+		"2:1:CREATE:1:execution reverted",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestTraceUnsupported
+func TestTraceUnsupported(t *testing.T) {
+	conf := &config.Config{}
+	conf.Indexer.Trace = true
+
+	backend := simulated.NewBackend(types.GenesisAlloc{})
+	defer backend.Close()
+
+	if _, err := newIndexer(conf, nil, backend); err == nil {
+		t.Error("expected trace unsupported error")
+	}
+}
+
+// tracingBackend a simulated backend returning canned traces.
+type tracingBackend struct {
+	*simulated.Backend
+	frames []*source.CallFrame
+	err    error
+}
+
+// TraceBlockCalls
+func (b *tracingBackend) TraceBlockCalls(ctx context.Context, hash common.Hash) ([]*source.CallFrame, error) {
+	return b.frames, b.err
+}
+
+// TestTraceProbe
+func TestTraceProbe(t *testing.T) {
+	conf := &config.Config{}
+	conf.Indexer.Trace = true
+
+	backend := &tracingBackend{
+		Backend: simulated.NewBackend(types.GenesisAlloc{}),
+		err:     errors.New("the method debug_traceBlockByHash does not exist/is not available"),
+	}
+	defer backend.Close()
+
+	if _, err := newIndexer(conf, nil, backend); err == nil {
+		t.Error("expected trace probe error")
+	}
+}
+
+// TestTraceTxMismatch
+func TestTraceTxMismatch(t *testing.T) {
+	idx, backend, _ := newIdleIndexer(t, 0)
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+	txs := []*chain.Tx{{Hash: common.HexToHash("0x1").Hex(), BlockNumber: 1}}
+
+	idx.conf.Indexer.Trace = true
+	idx.client = &tracingBackend{
+		Backend: backend,
+		frames:  []*source.CallFrame{{Type: "CALL", TxHash: common.HexToHash("0x2")}},
+	}
+
+	if _, err := idx.fetchInternalTxs(context.Background(), block, txs); err == nil {
+		t.Error("expected a tx mismatch error")
+	}
+
+	// nodes not reporting the tx hash
+	idx.client.(*tracingBackend).frames[0].TxHash = common.Hash{}
+
+	if _, err := idx.fetchInternalTxs(context.Background(), block, txs); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestScanContractCreation
+func TestScanContractCreation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		from: {Balance: big.NewInt(1e18)},
+	})
+
+	ctx := context.Background()
+
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// init code returning the 1 byte runtime code 0x00
+	initCode := common.FromHex("0x600060005360016000f3")
+
+	tx, err := types.SignTx(
+		types.NewContractCreation(0, big.NewInt(0), 100000, gasPrice, initCode),
+		types.LatestSignerForChainID(chainID),
+		key,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.SendTransaction(ctx, tx); err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	if err := idx.scan(1); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetTx(ctx, tx.Hash().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.To != "" {
+		t.Errorf("got to %q, want empty", got.To)
+	}
+
+	address := crypto.CreateAddress(from, 0)
+
+	contract, err := store.GetContract(ctx, address.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contract.Deployer != from.Hex() ||
+		contract.TxHash != tx.Hash().Hex() ||
+		contract.BlockNumber != 1 ||
+		contract.CodeHash != crypto.Keccak256Hash([]byte{0x00}).Hex() {
+		t.Errorf("unexpected contract %+v", contract)
+	}
+
+	contracts, err := store.GetDeployerContracts(ctx, from.Hex(), 0, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(contracts) != 1 || contracts[0].Address != address.Hex() {
+		t.Errorf("unexpected deployer contracts %+v", contracts)
+	}
+}
+
+// batcher the simulated backend as a batch source, counting round trips.
+type batcher struct {
+	*simulated.Backend
+	calls atomic.Int64
+}
+
+// BlocksByNumber
+func (b *batcher) BlocksByNumber(ctx context.Context, numbers []*big.Int) ([]*types.Block, error) {
+	b.calls.Add(1)
+
+	var blocks = []*types.Block{}
+	for _, n := range numbers {
+		block, err := b.BlockByNumber(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+// TransactionReceipts
+func (b *batcher) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, error) {
+	b.calls.Add(1)
+
+	var receipts = []*types.Receipt{}
+	for _, h := range hashes {
+		r, err := b.TransactionReceipt(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, r)
+	}
+
+	return receipts, nil
+}
+
+// sendTxs commits n blocks with a transfer each.
+func sendTxs(t *testing.T, backend *simulated.Backend, key *ecdsa.PrivateKey, n int) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	gasPrice, err := backend.SuggestGasPrice(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	for nonce := uint64(0); nonce < uint64(n); nonce++ {
+		tx, err := types.SignTx(
+			types.NewTransaction(nonce, to, big.NewInt(1000), 21000, gasPrice, nil),
+			types.LatestSignerForChainID(chainID),
+			key,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := backend.SendTransaction(ctx, tx); err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+	}
+}
+
+// TestFetchBatch
+func TestFetchBatch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	})
+
+	sendTxs(t, backend, key, 3)
+
+	// set before any job is pushed
+	client := &batcher{Backend: backend}
+	idx.client = client
+
+	fetched := idx.fetch([]int64{1, 2, 3})
+	if len(fetched) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(fetched))
+	}
+
+	// one round trip for blocks, one for receipts
+	if n := client.calls.Load(); n != 2 {
+		t.Errorf("got %d calls, want 2", n)
+	}
+
+	ctx := context.Background()
+
+	for i, f := range fetched {
+		if f.batch.Block.Number != int64(i+1) || len(f.batch.Txs) != 1 || f.batch.Txs[0].Receipt == nil {
+			t.Fatalf("unexpected block %+v", f.batch.Block)
+		}
+
+		if err := idx.storeBlock(ctx, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	block, err := store.GetBlock(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.TxCount != 1 || block.TotalDifficulty != "" {
+		t.Errorf("unexpected block %+v", block)
+	}
+}
+
+// receipts answers every receipt with err.
+type receipts struct {
+	*simulated.Backend
+	err error
+}
+
+// TransactionReceipt
+func (r *receipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return nil, r.err
+}
+
+// TestFetchReceipts
+func TestFetchReceipts(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	idx, backend, _ := newTestIndexer(t, types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	})
+
+	sendTxs(t, backend, key, 1)
+
+	// not indexed yet, retried later
+	idx.client = &receipts{Backend: backend, err: ethereum.NotFound}
+
+	if fetched := idx.fetch([]int64{1}); len(fetched) != 0 {
+		t.Fatalf("got %d blocks, want none without receipts", len(fetched))
+	}
+
+	// e.g. a replay file
+	idx.client = &receipts{Backend: backend, err: source.ErrReceiptUnsupported}
+
+	fetched := idx.fetch([]int64{1})
+	if len(fetched) != 1 || len(fetched[0].batch.Txs) != 1 || fetched[0].batch.Txs[0].Receipt != nil {
+		t.Fatalf("got %+v, want block 1 with a tx without receipt", fetched)
+	}
+}
+
+// TestTotalDifficulty
+func TestTotalDifficulty(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 0)
+
+	for i := 0; i < 4; i++ {
+		backend.Commit()
+	}
+
+	ctx := context.Background()
+
+	var (
+		total = new(big.Int)
+		want  = []string{}
+	)
+
+	for id := int64(0); id <= 4; id++ {
+		header, err := backend.HeaderByNumber(ctx, big.NewInt(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		total.Add(total, header.Difficulty)
+		want = append(want, total.String())
+	}
+
+	// saved before their parent
+	for _, id := range []int64{0, 2, 3, 4} {
+		scanBlocks(t, idx, id, id)
+	}
+
+	td, err := store.GetTotalDifficulty(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if td != "" {
+		t.Errorf("got total difficulty %q, want empty until block 1 is saved", td)
+	}
+
+	scanBlocks(t, idx, 1, 1)
+
+	for id := int64(0); id <= 4; id++ {
+		td, err := store.GetTotalDifficulty(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if td != want[id] {
+			t.Errorf("got block %d total difficulty %q, want %s", id, td, want[id])
+		}
+	}
+}
+
+// TestTotalDifficultyStale
+func TestTotalDifficultyStale(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 0)
+
+	backend.Commit()
+	backend.Commit()
+
+	ctx := context.Background()
+
+	// saved before its parent
+	scanBlocks(t, idx, 0, 0)
+	scanBlocks(t, idx, 2, 2)
+
+	// another block 1, e.g. from a fork
+	stale := types.NewBlockWithHeader(&types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(1),
+		Extra:      []byte("stale"),
+	})
+
+	if err := idx.fillTotalDifficulty(ctx, stale, "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	td, err := store.GetTotalDifficulty(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if td != "" {
+		t.Errorf("got total difficulty %q, want empty, block 2 doesn't build on the stale block", td)
+	}
+}
+
+// TestPipeline
+func TestPipeline(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	})
+
+	sendTxs(t, backend, key, 5)
+
+	for id := int64(0); id <= 5; id++ {
+		idx.push(id)
+	}
+
+	ctx := context.Background()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for id := int64(0); id <= 5; id++ {
+		for !store.HasScanned(ctx, id) {
+			if time.Now().After(deadline) {
+				t.Fatalf("block %d not scanned", id)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	balance, err := store.GetBalance(ctx, common.HexToAddress("0xaa").Hex(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance.Balance != "5000" {
+		t.Errorf("got balance %s, want 5000", balance.Balance)
+	}
+}
+
+// heads the simulated backend with head subscriptions
+// that can be dropped, or none at all.
+type heads struct {
+	*simulated.Backend
+	unsupported bool
+	subscribed  atomic.Int64
+	last        atomic.Pointer[droppable]
+}
+
+// droppable a subscription failing on drop.
+type droppable struct {
+	ethereum.Subscription
+	err chan error
+}
+
+// Err
+func (d *droppable) Err() <-chan error {
+	return d.err
+}
+
+// SubscribeNewHead
+func (h *heads) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if h.unsupported {
+		return nil, source.ErrSubscriptionUnsupported
+	}
+
+	sub, err := h.Backend.SubscribeNewHead(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &droppable{Subscription: sub, err: make(chan error, 1)}
+	h.last.Store(d)
+	h.subscribed.Add(1)
+
+	return d, nil
+}
+
+// waitFor fails t unless cond is met within 5s.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitScanned waits for blocks i to j to be scanned.
+func waitScanned(t *testing.T, store StoreWriter, i, j int64) {
+	t.Helper()
+
+	for id := i; id <= j; id++ {
+		waitFor(t, fmt.Sprintf("block %d", id), func() bool {
+			return store.HasScanned(context.Background(), id)
+		})
+	}
+}
+
+// follow scans from genesis & follows the chain head.
+func follow(t *testing.T, idx *Indexer) {
+	t.Helper()
+
+	if err := idx.scanRange(&chain.ScanRange{Follow: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTrackReconnect
+func TestTrackReconnect(t *testing.T) {
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{})
+
+	// set before following
+	client := &heads{Backend: backend}
+	idx.client = client
+	idx.conf.Indexer.Poll = time.Hour
+
+	follow(t, idx)
+	waitFor(t, "a subscription", func() bool { return client.subscribed.Load() == 1 })
+
+	backend.Commit()
+	backend.Commit()
+	waitScanned(t, store, 0, 2)
+
+	// blocks mined while disconnected
+	client.last.Load().err <- errors.New("connection reset")
+	backend.Commit()
+	backend.Commit()
+	backend.Commit()
+
+	waitFor(t, "a new subscription", func() bool { return client.subscribed.Load() == 2 })
+
+	backend.Commit()
+	waitScanned(t, store, 0, 6)
+
+	gaps, err := store.GetGaps(context.Background(), 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gaps) != 0 {
+		t.Errorf("got gaps %+v, want none", gaps)
+	}
+
+	if head := idx.head.Load(); head != 6 {
+		t.Errorf("got head %d, want 6", head)
+	}
+}
+
+// TestTrackPolling
+func TestTrackPolling(t *testing.T) {
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{})
+
+	// set before following
+	idx.client = &heads{Backend: backend, unsupported: true}
+	idx.conf.Indexer.Poll = 20 * time.Millisecond
+
+	follow(t, idx)
+
+	for i := 0; i < 3; i++ {
+		backend.Commit()
+	}
+
+	waitScanned(t, store, 0, 3)
+	waitFor(t, "the head", func() bool { return idx.head.Load() == 3 })
+
+	ranges, err := store.GetScanRanges(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || ranges[0].Done {
+		t.Errorf("got ranges %+v, want one followed", ranges)
+	}
+}
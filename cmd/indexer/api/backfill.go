@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// backfill periodically re-enqueues blocks missing
+// from the scan ranges, e.g. after a failed scan.
+func (idx *Indexer) backfill() {
+	interval := idx.conf.Indexer.Backfill
+	if interval <= 0 {
+		return
+	}
+
+	idx.wg.Add(1)
+	go func() {
+		defer idx.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-idx.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := idx.fillGaps(); err != nil {
+					idx.log.Println("idx_backfill", err)
+				}
+			}
+		}
+	}()
+}
+
+// fillGaps enqueues missing blocks of every scan range.
+func (idx *Indexer) fillGaps() error {
+	gaps, err := idx.findGaps()
+	if err != nil {
+		return err
+	}
+
+	for _, g := range gaps {
+		// log
+		idx.log.Printf("backfilling missing blocks %d to %d", g.From, g.To)
+
+		for i := g.From; i <= g.To; i++ {
+			if !idx.push(i) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+// findGaps
+func (idx *Indexer) findGaps() ([]*chain.Gap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	ranges, err := idx.store.GetScanRanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// blocks still in the queue are not missing
+	inflight := idx.inflight()
+
+	var gaps = []*chain.Gap{}
+
+	for _, r := range ranges {
+		end := r.End
+		if !r.Done {
+			end = r.Cursor - 1 - inflight
+		}
+
+		if end < r.Start {
+			continue
+		}
+
+		found, err := idx.store.GetGaps(ctx, r.Start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		gaps = append(gaps, found...)
+	}
+
+	return gaps, nil
+}
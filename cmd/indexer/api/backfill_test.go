@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// TestFillGaps
+func TestFillGaps(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 0)
+
+	for i := 0; i < 8; i++ {
+		backend.Commit()
+	}
+
+	// 2, 4 & 7 failed
+	for _, id := range []int64{0, 1, 3, 5, 6, 8} {
+		scanBlocks(t, idx, id, id)
+	}
+
+	ctx := context.Background()
+
+	// blocks of the pending range up to 8 are past the queue
+	var (
+		done    = &chain.ScanRange{Start: 0, End: 5, Cursor: 6, Done: true}
+		pending = &chain.ScanRange{Start: 6, End: 100, Cursor: 9 + idx.inflight()}
+	)
+
+	for _, r := range []*chain.ScanRange{done, pending} {
+		if err := store.SaveScanRange(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := idx.fillGaps(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := queued(t, idx, 3), []int64{2, 4, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v re-enqueued, want %v", got, want)
+	}
+
+	select {
+	case id := <-idx.jobs:
+		t.Fatalf("got block %d queued, want none left", id)
+	default:
+	}
+}
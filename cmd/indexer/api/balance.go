@@ -0,0 +1,187 @@
+package api
+
+import (
+	"math/big"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// rewardConfigs chains with proof-of-work block rewards, by chain id.
+var rewardConfigs = map[uint64]*params.ChainConfig{}
+
+func init() {
+	for _, c := range []*params.ChainConfig{
+		params.MainnetChainConfig,
+		params.SepoliaChainConfig,
+		params.AllEthashProtocolChanges, // dev chains
+	} {
+		rewardConfigs[c.ChainID.Uint64()] = c
+	}
+}
+
+// balanceDeltas derives the native balance changes of block: value
+// transfers, gas & blob gas fees paid by senders, tips and rewards
+// credited to miners, beacon withdrawals.
+// Transfers made by contracts are taken from the internal txs,
+// so they are only visible when the block is traced.
+func balanceDeltas(chainID *big.Int, block *types.Block, txs []*chain.Tx, internal []*chain.InternalTx) []*chain.BalanceDelta {
+	var (
+		ledger   = utils.NewLedger()
+		coinbase = block.Coinbase().Hex()
+		baseFee  = block.BaseFee()
+	)
+
+	for _, t := range txs {
+		// no receipt, assume success & skip fees
+		if t.Receipt == nil {
+			ledger.Transfer(t.From, t.To, t.Amount)
+			continue
+		}
+
+		// failed transactions pay gas but move no value
+		if succeeded(t.Receipt) {
+			to := t.To
+			if t.Receipt.ContractAddress != "" {
+				to = t.Receipt.ContractAddress
+			}
+
+			ledger.Transfer(t.From, to, t.Amount)
+		}
+
+		price, ok := new(big.Int).SetString(t.Receipt.EffectiveGasPrice, 10)
+		if !ok {
+			continue
+		}
+
+		gas := new(big.Int).SetUint64(t.Receipt.GasUsed)
+
+		// base fee is burned, the tip goes to the miner
+		tip := new(big.Int).Set(price)
+		if baseFee != nil {
+			tip.Sub(tip, baseFee)
+			ledger.Transfer(t.From, utils.ZeroAddress, new(big.Int).Mul(gas, baseFee).String())
+		}
+
+		ledger.Transfer(t.From, coinbase, new(big.Int).Mul(gas, tip).String())
+
+		// blob gas is burned whole
+		if blobPrice, ok := new(big.Int).SetString(t.Receipt.BlobGasPrice, 10); ok {
+			blobGas := new(big.Int).SetUint64(t.Receipt.BlobGasUsed)
+			ledger.Transfer(t.From, utils.ZeroAddress, blobGas.Mul(blobGas, blobPrice).String())
+		}
+	}
+
+	internalTransfers(ledger, txs, internal)
+
+	// withdrawals are credited in gwei
+	for _, w := range block.Withdrawals() {
+		amount := new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(params.GWei))
+		ledger.Transfer(utils.ZeroAddress, w.Address.Hex(), amount.String())
+	}
+
+	// block & uncle rewards
+	for address, reward := range blockRewards(chainID, block) {
+		ledger.Transfer(utils.ZeroAddress, address, reward.String())
+	}
+
+	var deltas = []*chain.BalanceDelta{}
+	for _, h := range ledger.Balances() {
+		deltas = append(deltas, &chain.BalanceDelta{
+			BlockNumber: block.Number().Int64(),
+			Address:     h.Address,
+			Delta:       h.Balance.String(),
+		})
+	}
+
+	return deltas
+}
+
+// internalTransfers applies the value moved by internal calls, a call
+// moves value only when its tx, itself & every call above it succeeded.
+func internalTransfers(ledger *utils.Ledger, txs []*chain.Tx, internal []*chain.InternalTx) {
+	var failed = map[string]bool{}
+	for _, t := range txs {
+		failed[t.Hash] = t.Receipt != nil && !succeeded(t.Receipt)
+	}
+
+	var (
+		tx       string
+		reverted []bool // by depth, whether a call or one above it reverted
+	)
+
+	for _, c := range internal {
+		if c.TxHash != tx {
+			tx, reverted = c.TxHash, reverted[:0]
+		}
+
+		r := failed[c.TxHash] || c.Error != ""
+		if c.Depth > 1 {
+			r = r || reverted[c.Depth-2]
+		}
+		reverted = append(reverted[:c.Depth-1], r)
+
+		if r {
+			continue
+		}
+
+		// delegate & static calls move no value,
+		// callcode keeps it with the caller.
+		switch c.Type {
+		case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+			ledger.Transfer(c.From, c.To, c.Value)
+		}
+	}
+}
+
+// blockRewards ethash block & uncle rewards by beneficiary,
+// none after the merge or on chains without proof-of-work.
+func blockRewards(chainID *big.Int, block *types.Block) map[string]*big.Int {
+	var rewards = map[string]*big.Int{}
+
+	config, found := rewardConfigs[chainID.Uint64()]
+	if !found || config.Ethash == nil || block.Difficulty().Sign() == 0 {
+		return rewards
+	}
+
+	var (
+		number = block.Number()
+		base   = ethash.FrontierBlockReward.ToBig()
+	)
+
+	if config.IsByzantium(number) {
+		base = ethash.ByzantiumBlockReward.ToBig()
+	}
+	if config.IsConstantinople(number) {
+		base = ethash.ConstantinopleBlockReward.ToBig()
+	}
+
+	add := func(address string, value *big.Int) {
+		if r, found := rewards[address]; found {
+			r.Add(r, value)
+			return
+		}
+		rewards[address] = value
+	}
+
+	// miner gets 1/32 of the base reward per included uncle,
+	// uncles get (uncle + 8 - number) / 8 of the base reward.
+	miner := new(big.Int).Set(base)
+	for _, uncle := range block.Uncles() {
+		r := new(big.Int).Add(uncle.Number, big.NewInt(8))
+		r.Sub(r, number)
+		r.Mul(r, base)
+		r.Div(r, big.NewInt(8))
+		add(uncle.Coinbase.Hex(), r)
+
+		miner.Add(miner, new(big.Int).Div(base, big.NewInt(32)))
+	}
+
+	add(block.Coinbase().Hex(), miner)
+
+	return rewards
+}
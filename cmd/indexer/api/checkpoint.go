@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// checkpointInterval number of enqueued blocks
+// between two persisted cursors of a scan range.
+const checkpointInterval = 100
+
+// scanRange persists r then adds its blocks to jobs queue.
+func (idx *Indexer) scanRange(r *chain.ScanRange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	if err := idx.store.SaveScanRange(ctx, r); err != nil {
+		return err
+	}
+
+	idx.wg.Add(1)
+	go idx.enqueue(r)
+
+	return nil
+}
+
+// resume enqueues the scan ranges left pending by a previous run.
+func (idx *Indexer) resume() error {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	ranges, err := idx.store.GetScanRanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	// blocks enqueued since the last checkpoint
+	// may not have been scanned before stopping.
+	rewind := idx.inflight() + checkpointInterval
+
+	for _, r := range ranges {
+		if r.Done {
+			continue
+		}
+
+		r.Cursor -= rewind
+		if r.Cursor < r.Start {
+			r.Cursor = r.Start
+		}
+
+		// catch up with blocks mined while stopped
+		if head := idx.head.Load(); r.Follow && r.End < head {
+			r.End = head
+		}
+
+		// log
+		idx.log.Printf("resuming scan range %d from block %d to %d", r.ID, r.Cursor, r.End)
+
+		idx.wg.Add(1)
+		go idx.enqueue(r)
+	}
+
+	return nil
+}
+
+// enqueue adds the remaining blocks of r to jobs queue,
+// persisting its cursor along the way.
+func (idx *Indexer) enqueue(r *chain.ScanRange) {
+	defer idx.wg.Done()
+
+	for r.Cursor <= r.End {
+		if !idx.push(r.Cursor) {
+			idx.checkpoint(r)
+			return
+		}
+
+		r.Cursor++
+
+		if (r.Cursor-r.Start)%checkpointInterval == 0 {
+			idx.checkpoint(r)
+		}
+	}
+
+	if r.Follow {
+		idx.follow(r)
+		return
+	}
+
+	r.Done = true
+	idx.checkpoint(r)
+}
+
+// follow starts the head tracker once r has been enqueued,
+// from then on r tracks the chain head.
+func (idx *Indexer) follow(r *chain.ScanRange) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	// head already followed by another range
+	if idx.tracking {
+		r.Done = true
+		idx.checkpoint(r)
+		return
+	}
+
+	idx.checkpoint(r)
+
+	// the tracker enqueues from the block after r.End
+	idx.head.Store(r.End)
+
+	idx.following = r
+	idx.tracking = true
+
+	idx.wg.Add(1)
+	go idx.track()
+}
+
+// checkpoint persists the state of r.
+func (idx *Indexer) checkpoint(r *chain.ScanRange) {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	if err := idx.store.UpdateScanRange(ctx, r); err != nil {
+		idx.log.Println("idx_store_update_scan_range", err)
+	}
+}
+
+// inflight max number of blocks taken from jobs queue
+// but not saved yet: being fetched, queued or being written.
+func (idx *Indexer) inflight() int64 {
+	return int64(cap(idx.jobs) + idx.conf.Indexer.Workers*idx.batch + cap(idx.writes) + idx.writers)
+}
+
+// push adds block id to jobs queue,
+// returns false once the indexer is stopped.
+func (idx *Indexer) push(id int64) bool {
+	select {
+	case <-idx.ctx.Done():
+		return false
+	case idx.jobs <- id:
+		return true
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// scanRanges by id
+func scanRanges(t *testing.T, store StoreWriter) map[int64]*chain.ScanRange {
+	t.Helper()
+
+	ranges, err := store.GetScanRanges(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var byID = map[int64]*chain.ScanRange{}
+	for _, r := range ranges {
+		byID[r.ID] = r
+	}
+
+	return byID
+}
+
+// TestResume
+func TestResume(t *testing.T) {
+	idx, _, store := newIdleIndexer(t, 0)
+
+	ctx := context.Background()
+
+	// left by a previous run
+	var (
+		partial = &chain.ScanRange{Start: 0, End: 500, Cursor: 300}
+		early   = &chain.ScanRange{Start: 100, End: 150, Cursor: 120}
+		done    = &chain.ScanRange{Start: 0, End: 10, Cursor: 11, Done: true}
+	)
+
+	for _, r := range []*chain.ScanRange{partial, early, done} {
+		if err := store.SaveScanRange(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := idx.resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocks between the last checkpoint & the stop may not have been saved
+	rewind := idx.inflight() + checkpointInterval
+
+	var (
+		want = map[int64]int64{}
+		got  = map[int64]int64{}
+	)
+
+	for id := 300 - rewind; id <= 500; id++ {
+		want[id]++
+	}
+
+	// rewound before its start
+	for id := int64(100); id <= 150; id++ {
+		want[id]++
+	}
+
+	var n int
+	for _, c := range want {
+		n += int(c)
+	}
+
+	for _, id := range queued(t, idx, n) {
+		got[id]++
+	}
+
+	for id, c := range want {
+		if got[id] != c {
+			t.Fatalf("got block %d queued %d times, want %d", id, got[id], c)
+		}
+	}
+
+	select {
+	case id := <-idx.jobs:
+		t.Fatalf("got block %d queued, want none left", id)
+	default:
+	}
+
+	waitFor(t, "completed ranges", func() bool {
+		ranges := scanRanges(t, store)
+		return ranges[partial.ID].Done && ranges[early.ID].Done
+	})
+
+	ranges := scanRanges(t, store)
+
+	if r := ranges[partial.ID]; r.Cursor != 501 || r.End != 500 {
+		t.Errorf("got range %+v, want cursor 501", r)
+	}
+
+	if r := ranges[early.ID]; r.Cursor != 151 {
+		t.Errorf("got range %+v, want cursor 151", r)
+	}
+
+	if r := ranges[done.ID]; r.Cursor != 11 {
+		t.Errorf("got range %+v, want done range untouched", r)
+	}
+}
+
+// TestResumeFollow
+func TestResumeFollow(t *testing.T) {
+	idx, _, store := newIdleIndexer(t, 0)
+
+	ctx := context.Background()
+
+	r := &chain.ScanRange{Start: 0, End: 10, Cursor: 5, Follow: true}
+	if err := store.SaveScanRange(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocks mined while stopped
+	idx.head.Store(20)
+
+	if err := idx.resume(); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := queued(t, idx, 21)
+	if ids[0] != 0 || ids[20] != 20 {
+		t.Errorf("got blocks %d to %d queued, want 0 to 20", ids[0], ids[20])
+	}
+
+	waitFor(t, "the followed range", func() bool {
+		idx.mu.Lock()
+		defer idx.mu.Unlock()
+
+		return idx.tracking && idx.following != nil && idx.following.ID == r.ID
+	})
+
+	stored := scanRanges(t, store)[r.ID]
+	if stored.Done || stored.End != 20 || stored.Cursor != 21 {
+		t.Errorf("got range %+v, want end 20 & cursor 21", stored)
+	}
+}
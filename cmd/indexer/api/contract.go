@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/source"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fetchContracts the contracts deployed by successful creation txs,
+// along with the hash of their runtime bytecode when the source has state.
+// Contracts created by other contracts (internal calls) are not recorded.
+func (idx *Indexer) fetchContracts(ctx context.Context, txs []*chain.Tx) []*chain.Contract {
+	var contracts = []*chain.Contract{}
+
+	for _, t := range txs {
+		if t.Receipt == nil || t.Receipt.ContractAddress == "" || !succeeded(t.Receipt) {
+			continue
+		}
+
+		c := &chain.Contract{
+			Address:     t.Receipt.ContractAddress,
+			Deployer:    t.From,
+			TxHash:      t.Hash,
+			BlockNumber: t.BlockNumber,
+		}
+
+		code, err := idx.client.CodeAt(ctx, common.HexToAddress(c.Address), big.NewInt(t.BlockNumber))
+		switch {
+		case err == nil:
+			c.CodeHash = crypto.Keccak256Hash(code).Hex()
+		case errors.Is(err, source.ErrStateUnsupported):
+		default:
+			// e.g. state pruned by the node
+			idx.log.Println("idx_client_code_at", err)
+		}
+
+		contracts = append(contracts, c)
+	}
+
+	return contracts
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/twiny/blockscan/pkg/source"
+)
+
+// fetcher takes batches of block ids from jobs queue,
+// fetches them & hands them over to the savers.
+func (idx *Indexer) fetcher() {
+	defer idx.wg.Done()
+
+	for {
+		ids, ok := idx.take()
+		if !ok {
+			return
+		}
+
+		for _, f := range idx.fetch(ids) {
+			select {
+			case <-idx.ctx.Done():
+				return
+			case idx.writes <- f:
+			}
+		}
+	}
+}
+
+// take waits for a block id then drains jobs queue up to a batch,
+// returns false once the indexer is stopped.
+func (idx *Indexer) take() ([]int64, bool) {
+	var ids = make([]int64, 0, idx.batch)
+
+	select {
+	case <-idx.ctx.Done():
+		return nil, false
+	case id := <-idx.jobs:
+		ids = append(ids, id)
+	}
+
+	for len(ids) < idx.batch {
+		select {
+		case id := <-idx.jobs:
+			ids = append(ids, id)
+		default:
+			return ids, true
+		}
+	}
+
+	return ids, true
+}
+
+// fetch the blocks of ids not scanned yet or replaced since, a failed
+// batch is retried block by block so a single bad block doesn't hold
+// back the others.
+func (idx *Indexer) fetch(ids []int64) []*fetched {
+	var pending = make([]int64, 0, len(ids))
+
+	for _, id := range ids {
+		if idx.hasScanned(id) && !idx.replaced(id) {
+			idx.log.Printf("block %d already scanned", id)
+			continue
+		}
+		pending = append(pending, id)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	fetched, err := idx.fetchWithTimeout(pending)
+	if err == nil {
+		return fetched
+	}
+
+	idx.log.Println(err)
+
+	if len(pending) == 1 {
+		return nil
+	}
+
+	for _, id := range pending {
+		f, err := idx.fetchWithTimeout([]int64{id})
+		if err != nil {
+			idx.log.Println(err)
+			continue
+		}
+
+		fetched = append(fetched, f...)
+	}
+
+	return fetched
+}
+
+// hasScanned
+func (idx *Indexer) hasScanned(id int64) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	return idx.store.HasScanned(ctx, id)
+}
+
+// replaced whether the stored block id is no longer canonical, e.g. a
+// new tip at the same height enqueued again, which has no child yet to
+// reveal the reorg.
+func (idx *Indexer) replaced(id int64) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	stored, err := idx.store.GetBlockHash(ctx, id)
+	if err != nil {
+		return false
+	}
+
+	header, err := idx.client.HeaderByNumber(ctx, big.NewInt(id))
+	if err != nil {
+		idx.log.Println("idx_replaced", err)
+		return false
+	}
+
+	return stored != header.Hash().Hex()
+}
+
+// fetchWithTimeout
+func (idx *Indexer) fetchWithTimeout(ids []int64) ([]*fetched, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	return idx.fetchBatch(ctx, ids)
+}
+
+// fetchBatch fetches blocks ids & everything indexed from them.
+func (idx *Indexer) fetchBatch(ctx context.Context, ids []int64) ([]*fetched, error) {
+	var numbers = make([]*big.Int, 0, len(ids))
+	for _, id := range ids {
+		numbers = append(numbers, big.NewInt(id))
+	}
+
+	// blocks & receipts of a single endpoint
+	blocks, receipts, err := source.FetchBlocks(ctx, idx.client, numbers)
+	if err != nil {
+		return nil, fmt.Errorf("blocks %d to %d: %w", ids[0], ids[len(ids)-1], err)
+	}
+
+	var batch = make([]*fetched, 0, len(blocks))
+
+	for i, block := range blocks {
+		f, err := idx.assemble(ctx, block, receipts[i])
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", block.Number(), err)
+		}
+
+		batch = append(batch, f)
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/source"
+)
+
+const (
+	// pollInterval default interval between latest block polls
+	pollInterval = 5 * time.Second
+
+	// minBackoff & maxBackoff bound the wait between two
+	// head subscriptions, doubled after each failure.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// errSubscriptionClosed a subscription ended without an error
+var errSubscriptionClosed = errors.New("head subscription closed")
+
+// track follows the chain head until the indexer stops. New heads are
+// pushed by a subscription when the chain source supports it, otherwise
+// the latest block number is polled. A failed subscription is retried
+// with exponential backoff, polling in the meantime so the tip keeps up.
+func (idx *Indexer) track() {
+	defer idx.wg.Done()
+
+	backoff := minBackoff
+
+	for {
+		received, err := idx.subscribe()
+		if idx.ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, source.ErrSubscriptionUnsupported) {
+			idx.log.Println("chain source can't push new heads, polling the latest block")
+			idx.poll(0)
+			return
+		}
+
+		// the subscription was up, start over
+		if received {
+			backoff = minBackoff
+		}
+
+		idx.log.Printf("idx_subscribe %v, retrying in %s", err, backoff)
+
+		if !idx.poll(backoff) {
+			return
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// subscribe enqueues new heads until the subscription fails
+// or the indexer stops, received reports whether any head came.
+func (idx *Indexer) subscribe() (received bool, err error) {
+	ctx, cancel := context.WithTimeout(idx.ctx, idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	sub, err := idx.client.SubscribeNewHead(ctx, idx.events)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+
+	// log
+	idx.log.Println("subscribed to new block")
+
+	// heads mined while not subscribed
+	if !idx.pollHead() {
+		return false, nil
+	}
+
+	for {
+		select {
+		case <-idx.ctx.Done():
+			return received, nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = errSubscriptionClosed
+			}
+			return received, err
+		case header := <-idx.events:
+			received = true
+
+			if !idx.advance(header.Number.Int64()) {
+				return received, nil
+			}
+		}
+	}
+}
+
+// poll enqueues new heads every poll interval for d, forever when d is 0,
+// returns false once the indexer is stopped.
+func (idx *Indexer) poll(d time.Duration) bool {
+	interval := idx.conf.Indexer.Poll
+	if interval <= 0 {
+		interval = pollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	for {
+		if !idx.pollHead() {
+			return false
+		}
+
+		select {
+		case <-idx.ctx.Done():
+			return false
+		case <-timeout:
+			return true
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollHead enqueues up to the latest block number,
+// returns false once the indexer is stopped.
+func (idx *Indexer) pollHead() bool {
+	ctx, cancel := context.WithTimeout(idx.ctx, idx.conf.Indexer.Timeout)
+	defer cancel()
+
+	n, err := idx.client.BlockNumber(ctx)
+	if err != nil {
+		idx.log.Println("idx_poll_head", err)
+		return idx.ctx.Err() == nil
+	}
+
+	// nothing new, or a lagging endpoint
+	if int64(n) <= idx.head.Load() {
+		return true
+	}
+
+	return idx.advance(int64(n))
+}
+
+// advance enqueues blocks from the last enqueued head up to head, so heights
+// missed in between (e.g. while disconnected) are scanned too. A head at or
+// below the last one (e.g. after a reorg) is enqueued again alone.
+func (idx *Indexer) advance(head int64) bool {
+	from := idx.head.Load() + 1
+	if head < from {
+		from = head
+	}
+
+	for id := from; id <= head; id++ {
+		if !idx.push(id) {
+			return false
+		}
+	}
+
+	// update head
+	idx.head.Store(head)
+
+	// checkpoint followed range
+	idx.mu.Lock()
+	if r := idx.following; r != nil {
+		r.End = head
+		r.Cursor = head + 1
+		idx.checkpoint(r)
+	}
+	idx.mu.Unlock()
+
+	return true
+}
@@ -3,21 +3,23 @@ package api
 import (
 	"context"
 	_ "embed"
+	"io"
 	"log"
+	"math/big"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/twiny/blockscan/pkg/chain"
 	"github.com/twiny/blockscan/pkg/config"
-	"github.com/twiny/blockscan/service/sqlite"
+	"github.com/twiny/blockscan/pkg/source"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-chi/chi/v5"
-	"github.com/twiny/ratelimit"
 )
 
 //go:embed version
@@ -32,16 +34,31 @@ type Indexer struct {
 	mux *chi.Mux
 	srv *http.Server
 	//
-	limiter *ratelimit.Limiter
-	client  *ethclient.Client
+	client  source.ChainSource // a pool of the configured endpoints
+	chainID *big.Int           // fetched once at startup
 	//
-	jobs chan int64 // queue of block ids to scan
+	jobs    chan int64    // queue of block ids to scan
+	writes  chan *fetched // queue of fetched blocks to save
+	batch   int           // max block ids fetched at once
+	writers int
 	//
-	// once used to only subscribe to
-	// `client.SubscribeNewHead` once.
-	subscribed bool
-	head       int64 // latest block
-	events     chan *types.Header
+	// mu guards tracking & following.
+	mu *sync.Mutex
+	//
+	// tracking once the head tracker runs,
+	// a single range follows the chain head.
+	tracking  bool
+	following *chain.ScanRange // range tracking the chain head
+	head      *atomic.Int64    // latest block enqueued
+	events    chan *types.Header
+	//
+	// reorg serializes chain reorganization
+	// rollbacks, reorgs counts them.
+	reorg  *sync.Mutex
+	reorgs *atomic.Int64
+	//
+	// difficulty serializes total difficulty back-fills
+	difficulty *sync.Mutex
 	//
 	store StoreWriter
 	//
@@ -58,31 +75,38 @@ func NewIndexer(path string) (*Indexer, error) {
 		return nil, err
 	}
 
-	//
-	mux := chi.NewRouter()
-
-	// db
-	store, err := sqlite.NewSQLiteDB(conf.Store.Path)
+	store, err := openStore(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := store.Ping(); err != nil {
+	client, err := dialPool(conf)
+	if err != nil {
 		return nil, err
 	}
 
+	return newIndexer(conf, store, client)
+}
+
+// newIndexer
+func newIndexer(conf *config.Config, store StoreWriter, client source.ChainSource) (*Indexer, error) {
 	//
-	if err := store.Migrate("up"); err != nil {
-		return nil, err
-	}
+	mux := chi.NewRouter()
 
-	client, err := ethclient.Dial(conf.Indexer.Endpoint)
+	// get current latest block
+	latest, err := client.HeaderByNumber(context.Background(), nil) // TODO: timeout context
 	if err != nil {
 		return nil, err
 	}
 
-	// get current latest block
-	latest, err := client.BlockByNumber(context.Background(), nil) // TODO: timeout context
+	if conf.Indexer.Trace {
+		if err := checkTrace(context.Background(), client, latest.Hash()); err != nil {
+			return nil, err
+		}
+	}
+
+	// never changes, no need to ask for every block
+	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -105,14 +129,23 @@ func NewIndexer(path string) (*Indexer, error) {
 			IdleTimeout:  10 * time.Second,
 		},
 		//
-		limiter: ratelimit.NewLimiter(conf.Indexer.Limiter.Rate, conf.Indexer.Limiter.Duration),
 		client:  client,
+		chainID: chainID,
+		//
+		jobs:    make(chan int64, 16),    // TODO: chan size in conf
+		writes:  make(chan *fetched, 16), // TODO: chan size in conf
+		batch:   max(conf.Indexer.Batch, 1),
+		writers: max(conf.Indexer.Writers, 1),
 		//
-		jobs: make(chan int64, 16), // TODO: chan size in conf
+		mu:       &sync.Mutex{},
+		tracking: false,
+		head:     &atomic.Int64{},
+		events:   make(chan *types.Header, 16), // TODO: chan size in conf
 		//
-		subscribed: false,
-		head:       latest.Number().Int64(),
-		events:     make(chan *types.Header, 16), // TODO: chan size in conf
+		reorg:  &sync.Mutex{},
+		reorgs: &atomic.Int64{},
+		//
+		difficulty: &sync.Mutex{},
 		//
 		store: store,
 		//
@@ -122,9 +155,22 @@ func NewIndexer(path string) (*Indexer, error) {
 		done: done,
 	}
 
+	idx.head.Store(latest.Number.Int64())
+
 	// start indexer
 	idx.indexer()
 
+	// check endpoints health
+	idx.watch()
+
+	// resume pending scan ranges
+	if err := idx.resume(); err != nil {
+		return nil, err
+	}
+
+	// backfill missing blocks
+	idx.backfill()
+
 	return idx, nil
 }
 
@@ -165,11 +211,21 @@ func (idx *Indexer) Shutdown() {
 		idx.log.Println(err)
 	}
 
+	// stop indexer
+	idx.done()
 	idx.wg.Wait()
 
 	close(idx.jobs)
+	close(idx.writes)
 	close(idx.events)
 
+	// e.g. writes the memory store snapshot
+	if c, ok := idx.store.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			idx.log.Println(err)
+		}
+	}
+
 	log.Println("goodbye.")
 	os.Exit(0)
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/twiny/blockscan/pkg/config"
+)
+
+// Migrate runs a schema migration command against the configured store:
+// `up`, `down` n migrations, `status` or `goto` version n.
+func Migrate(path string, cmd string, n int) error {
+	conf, err := config.ParseConfig(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := dialStore(conf)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	v, ok := store.(versioned)
+	if !ok {
+		return fmt.Errorf("store driver %q has no schema to migrate", conf.Store.Driver)
+	}
+
+	m, err := v.Migrator()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch cmd {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx, n)
+	case "goto":
+		err = m.Goto(ctx, n)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			applied := "pending"
+			if !s.AppliedAt.IsZero() {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			log.Printf("%04d_%s: %s", s.Version, s.Name, applied)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q", cmd)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("schema at version %d of %d", version, m.Latest())
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// toReceipt the receipt of tx along with its logs,
+// nil when the source doesn't support receipts (e.g. a replay file).
+func toReceipt(block *types.Block, tx *types.Transaction, receipt *types.Receipt) *chain.Receipt {
+	if receipt == nil {
+		return nil
+	}
+
+	r := &chain.Receipt{
+		TxHash:            tx.Hash().Hex(),
+		BlockNumber:       block.Number().Int64(),
+		Status:            receipt.Status,
+		GasUsed:           receipt.GasUsed,
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		EffectiveGasPrice: utils.EffectiveGasPrice(tx, block.BaseFee()).String(),
+		Logs:              make([]*chain.Log, 0, len(receipt.Logs)),
+	}
+
+	if len(receipt.PostState) > 0 {
+		r.PostState = hexutil.Encode(receipt.PostState)
+	}
+
+	if !utils.IsZeroAddress(receipt.ContractAddress) {
+		r.ContractAddress = receipt.ContractAddress.Hex()
+	}
+
+	if receipt.BlobGasPrice != nil {
+		r.BlobGasUsed = receipt.BlobGasUsed
+		r.BlobGasPrice = receipt.BlobGasPrice.String()
+	}
+
+	for _, l := range receipt.Logs {
+		topics := make([]string, 0, len(l.Topics))
+		for _, t := range l.Topics {
+			topics = append(topics, t.Hex())
+		}
+
+		r.Logs = append(r.Logs, &chain.Log{
+			TxHash:      r.TxHash,
+			BlockNumber: r.BlockNumber,
+			Index:       l.Index,
+			Address:     l.Address.Hex(),
+			Topics:      topics,
+			Data:        hexutil.Encode(l.Data),
+		})
+	}
+
+	return r
+}
+
+// succeeded whether the tx of r succeeded, receipts before Byzantium
+// have a post state instead of a status & are taken as successful.
+func succeeded(r *chain.Receipt) bool {
+	return r.Status == types.ReceiptStatusSuccessful || r.PostState != ""
+}
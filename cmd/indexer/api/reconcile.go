@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/source"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// mismatch
+type mismatch struct {
+	Address string
+	Derived *big.Int // change derived from indexed blocks
+	Actual  *big.Int // change reported by the chain source
+}
+
+// Reconcile spot-checks the balance changes derived in blocks (from, to]
+// against `BalanceAt` on the chain source, for addresses or a random
+// sample of addresses active in the range.
+func Reconcile(path string, from, to int64, sample int, addresses []string) error {
+	conf, err := config.ParseConfig(path)
+	if err != nil {
+		return err
+	}
+
+	store, err := openStore(conf)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	client, err := dialPool(conf)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// checksum format, as stored
+	for i, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid address %q", address)
+		}
+		addresses[i] = common.HexToAddress(address).Hex()
+	}
+
+	if len(addresses) == 0 {
+		addresses, err = store.GetBalanceAddresses(ctx, from+1, to, sample)
+		if err != nil {
+			return err
+		}
+	}
+
+	mismatches, err := reconcile(ctx, store, client, from, to, addresses)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mismatches {
+		log.Printf("mismatch %s: derived %s, actual %s", m.Address, m.Derived, m.Actual)
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d of %d balances mismatch", len(mismatches), len(addresses))
+	}
+
+	log.Printf("%d balances reconciled in blocks %d:%d", len(addresses), from, to)
+
+	return nil
+}
+
+// reconcile
+func reconcile(ctx context.Context, store StoreWriter, client source.ChainSource, from, to int64, addresses []string) ([]*mismatch, error) {
+	if from < 0 || to <= from {
+		return nil, fmt.Errorf("invalid range %d:%d", from, to)
+	}
+
+	// derived changes are only complete without gaps
+	gaps, err := store.GetGaps(ctx, from+1, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(gaps) > 0 {
+		return nil, fmt.Errorf("%d gaps in blocks %d:%d, first at %d", len(gaps), from+1, to, gaps[0].From)
+	}
+
+	var mismatches = []*mismatch{}
+
+	for _, address := range addresses {
+		derived, err := derivedChange(ctx, store, address, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		actual, err := actualChange(ctx, client, common.HexToAddress(address), from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		if derived.Cmp(actual) != 0 {
+			mismatches = append(mismatches, &mismatch{
+				Address: address,
+				Derived: derived,
+				Actual:  actual,
+			})
+		}
+	}
+
+	return mismatches, nil
+}
+
+// derivedChange
+func derivedChange(ctx context.Context, store StoreWriter, address string, from, to int64) (*big.Int, error) {
+	var values [2]*big.Int
+
+	for i, n := range []int64{from, to} {
+		b, err := store.GetBalance(ctx, address, n)
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := new(big.Int).SetString(b.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("address %s: invalid balance %q", address, b.Balance)
+		}
+
+		values[i] = value
+	}
+
+	return values[1].Sub(values[1], values[0]), nil
+}
+
+// actualChange
+func actualChange(ctx context.Context, client source.ChainSource, address common.Address, from, to int64) (*big.Int, error) {
+	before, err := client.BalanceAt(ctx, address, big.NewInt(from))
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := client.BalanceAt(ctx, address, big.NewInt(to))
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Sub(after, before), nil
+}
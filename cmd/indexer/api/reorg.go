@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultReorgDepth used when `reorg_depth` is not set.
+const defaultReorgDepth = 64
+
+// checkReorg compares block against its stored neighbours: on a parent
+// mismatch rolls back to the common ancestor, on a child mismatch rolls
+// forward over the stale children. Blocks are saved out of order by
+// concurrent savers & backfills, so either side may be stored first.
+// A stale block stored at the same height is rolled forward too.
+func (idx *Indexer) checkReorg(ctx context.Context, block *types.Block) error {
+	if err := idx.checkReplaced(ctx, block); err != nil {
+		return err
+	}
+
+	if err := idx.checkParent(ctx, block); err != nil {
+		return err
+	}
+
+	return idx.checkChild(ctx, block)
+}
+
+// checkReplaced compares the block stored at the height of block, if any,
+// against the canonical chain, e.g. a tip replaced at the same height.
+func (idx *Indexer) checkReplaced(ctx context.Context, block *types.Block) error {
+	id := block.Number().Int64()
+	if id == 0 {
+		return nil
+	}
+
+	stored, err := idx.store.GetBlockHash(ctx, id)
+	if err != nil {
+		// not indexed yet
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	// e.g. saved by another worker, refused on save
+	if stored == block.Hash().Hex() {
+		return nil
+	}
+
+	header, err := idx.client.HeaderByNumber(ctx, block.Number())
+	if err != nil {
+		return err
+	}
+
+	if header.Hash() != block.Hash() {
+		return fmt.Errorf("block %d is not on the canonical chain", id)
+	}
+
+	return idx.rollforward(ctx, id-1)
+}
+
+// checkParent compares the parent hash of block against
+// the stored hash of its parent.
+func (idx *Indexer) checkParent(ctx context.Context, block *types.Block) error {
+	id := block.Number().Int64()
+	if id == 0 {
+		return nil
+	}
+
+	parent, err := idx.store.GetBlockHash(ctx, id-1)
+	if err != nil {
+		// parent not indexed yet
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if parent == block.ParentHash().Hex() {
+		return nil
+	}
+
+	return idx.rollback(ctx, id)
+}
+
+// checkChild compares the stored child of block against the canonical
+// chain, a stale child is rolled forward & a stale block is refused.
+func (idx *Indexer) checkChild(ctx context.Context, block *types.Block) error {
+	id := block.Number().Int64()
+
+	child, err := idx.store.GetBlockHash(ctx, id+1)
+	if err != nil {
+		// child not indexed yet
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	header, err := idx.client.HeaderByNumber(ctx, big.NewInt(id+1))
+	if err != nil {
+		return err
+	}
+
+	if child != header.Hash().Hex() {
+		if err := idx.rollforward(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	// canonical child doesn't descend from block, block itself is stale
+	if header.ParentHash != block.Hash() {
+		return fmt.Errorf("block %d is not on the canonical chain", id)
+	}
+
+	return nil
+}
+
+// rollback walks back from block id to the common ancestor of the stored
+// and canonical chains, deletes the orphaned blocks & re-index them.
+func (idx *Indexer) rollback(ctx context.Context, id int64) error {
+	idx.reorg.Lock()
+	defer idx.reorg.Unlock()
+
+	max := idx.conf.Indexer.ReorgDepth
+	if max <= 0 {
+		max = defaultReorgDepth
+	}
+
+	ancestor := id - 1
+	for ; ancestor >= 0; ancestor-- {
+		if id-ancestor > max {
+			return fmt.Errorf("reorg at block %d deeper than %d blocks", id, max)
+		}
+
+		stored, err := idx.store.GetBlockHash(ctx, ancestor)
+		if err != nil {
+			// not indexed, nothing to compare against
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return err
+		}
+
+		header, err := idx.client.HeaderByNumber(ctx, big.NewInt(ancestor))
+		if err != nil {
+			return err
+		}
+
+		if stored == header.Hash().Hex() {
+			// stored parent is canonical, block itself is stale
+			if ancestor == id-1 {
+				return fmt.Errorf("block %d is not on the canonical chain", id)
+			}
+			break
+		}
+	}
+
+	// already rolled back by another worker
+	if ancestor == id-1 {
+		return nil
+	}
+
+	if err := idx.store.DeleteBlocks(ctx, ancestor+1, id-1); err != nil {
+		return err
+	}
+
+	idx.reorgs.Add(1)
+
+	// log
+	idx.log.Printf("reorg detected at block %d, depth %d, common ancestor %d", id, id-1-ancestor, ancestor)
+
+	idx.reindex(ancestor+1, id-1)
+
+	return nil
+}
+
+// rollforward walks forward from the child of block id to the first stored
+// block still canonical, deletes the stale blocks in between & re-index them.
+func (idx *Indexer) rollforward(ctx context.Context, id int64) error {
+	idx.reorg.Lock()
+	defer idx.reorg.Unlock()
+
+	max := idx.conf.Indexer.ReorgDepth
+	if max <= 0 {
+		max = defaultReorgDepth
+	}
+
+	end := id + 1
+	for ; ; end++ {
+		if end-id > max {
+			return fmt.Errorf("reorg after block %d deeper than %d blocks", id, max)
+		}
+
+		stored, err := idx.store.GetBlockHash(ctx, end)
+		if err != nil {
+			// not indexed, nothing to compare against
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return err
+		}
+
+		header, err := idx.client.HeaderByNumber(ctx, big.NewInt(end))
+		if err != nil {
+			return err
+		}
+
+		if stored == header.Hash().Hex() {
+			break
+		}
+	}
+
+	// already rolled forward by another worker
+	if end == id+1 {
+		return nil
+	}
+
+	if err := idx.store.DeleteBlocks(ctx, id+1, end-1); err != nil {
+		return err
+	}
+
+	idx.reorgs.Add(1)
+
+	// log
+	idx.log.Printf("reorg detected after block %d, depth %d", id, end-1-id)
+
+	idx.reindex(id+1, end-1)
+
+	return nil
+}
+
+// reindex pushes canonical blocks i to j back to jobs queue.
+func (idx *Indexer) reindex(i, j int64) {
+	idx.wg.Add(1)
+	go func() {
+		defer idx.wg.Done()
+
+		for id := i; id <= j; id++ {
+			if !idx.push(id) {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/source/simulated"
+	"github.com/twiny/blockscan/service/sqlite"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// newIdleIndexer an indexer without fetchers,
+// re-indexed blocks stay in jobs queue for the test.
+func newIdleIndexer(t *testing.T, depth int64) (*Indexer, *simulated.Backend, *sqlite.SQLite) {
+	t.Helper()
+
+	return newTestIndexerWith(t, types.GenesisAlloc{}, func(conf *config.Config) {
+		conf.Indexer.Workers = 0
+		conf.Indexer.ReorgDepth = depth
+	})
+}
+
+// fork replaces the blocks after id with n new ones.
+func fork(t *testing.T, backend *simulated.Backend, id int64, n int) {
+	t.Helper()
+
+	parent, err := backend.HeaderByNumber(context.Background(), big.NewInt(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.Fork(parent.Hash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// a later timestamp, so new blocks differ from the stale ones
+	if err := backend.AdjustTime(10 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < n; i++ {
+		backend.Commit()
+	}
+}
+
+// scanBlocks scans blocks i to j.
+func scanBlocks(t *testing.T, idx *Indexer, i, j int64) {
+	t.Helper()
+
+	for id := i; id <= j; id++ {
+		if err := idx.scan(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// queued the next n block ids of jobs queue.
+func queued(t *testing.T, idx *Indexer, n int) []int64 {
+	t.Helper()
+
+	var ids = []int64{}
+	for len(ids) < n {
+		select {
+		case id := <-idx.jobs:
+			ids = append(ids, id)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %v re-indexed, want %d blocks", ids, n)
+		}
+	}
+
+	return ids
+}
+
+// scanned reports which of blocks i to j are stored.
+func scanned(store StoreWriter, i, j int64) []bool {
+	var found = []bool{}
+	for id := i; id <= j; id++ {
+		found = append(found, store.HasScanned(context.Background(), id))
+	}
+	return found
+}
+
+// TestReorg
+func TestReorg(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 0)
+
+	for i := 0; i < 5; i++ {
+		backend.Commit()
+	}
+	scanBlocks(t, idx, 0, 5)
+
+	// 3 to 5 replaced by 3 to 6
+	fork(t, backend, 2, 4)
+
+	// parent of 6 is stale
+	scanBlocks(t, idx, 6, 6)
+
+	if got, want := scanned(store, 2, 6), []bool{true, false, false, false, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got blocks 2 to 6 scanned %v, want %v", got, want)
+	}
+
+	if got, want := queued(t, idx, 3), []int64{3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v re-indexed, want %v", got, want)
+	}
+
+	if n := idx.reorgs.Load(); n != 1 {
+		t.Errorf("got %d reorgs, want 1", n)
+	}
+
+	// already rolled back, e.g. by another saver
+	if err := idx.rollback(context.Background(), 6); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := idx.reorgs.Load(); n != 1 {
+		t.Errorf("got %d reorgs, want 1", n)
+	}
+
+	// re-indexed from the canonical chain
+	scanBlocks(t, idx, 3, 5)
+
+	for id := int64(3); id <= 5; id++ {
+		header, err := backend.HeaderByNumber(context.Background(), big.NewInt(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hash, err := store.GetBlockHash(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hash != header.Hash().Hex() {
+			t.Errorf("got block %d hash %s, want %s", id, hash, header.Hash().Hex())
+		}
+	}
+}
+
+// TestReorgDepth
+func TestReorgDepth(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 2)
+
+	for i := 0; i < 5; i++ {
+		backend.Commit()
+	}
+	scanBlocks(t, idx, 0, 5)
+
+	// 2 to 5 replaced by 2 to 6
+	fork(t, backend, 1, 5)
+
+	err := idx.scan(6)
+	if err == nil || !strings.Contains(err.Error(), "deeper than 2 blocks") {
+		t.Fatalf("got %v, want a reorg deeper than 2 blocks", err)
+	}
+
+	if got, want := scanned(store, 1, 6), []bool{true, true, true, true, true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got blocks 1 to 6 scanned %v, want %v", got, want)
+	}
+
+	if n := idx.reorgs.Load(); n != 0 {
+		t.Errorf("got %d reorgs, want 0", n)
+	}
+}
+
+// TestReorgChild
+func TestReorgChild(t *testing.T) {
+	idx, backend, store := newIdleIndexer(t, 0)
+
+	for i := 0; i < 5; i++ {
+		backend.Commit()
+	}
+	scanBlocks(t, idx, 0, 2)
+	scanBlocks(t, idx, 4, 4)
+
+	// fetched before the reorg, saved after
+	stale := idx.fetch([]int64{3})
+	if len(stale) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(stale))
+	}
+
+	// 3 to 5 replaced by 3 to 6
+	fork(t, backend, 2, 4)
+
+	ctx := context.Background()
+
+	// stored child 4 is stale too
+	err := idx.storeBlock(ctx, stale[0])
+	if err == nil || !strings.Contains(err.Error(), "not on the canonical chain") {
+		t.Fatalf("got %v, want block 3 not on the canonical chain", err)
+	}
+
+	if got, want := scanned(store, 2, 4), []bool{true, false, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got blocks 2 to 4 scanned %v, want %v", got, want)
+	}
+
+	if got, want := queued(t, idx, 1), []int64{4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v re-indexed, want %v", got, want)
+	}
+
+	if n := idx.reorgs.Load(); n != 1 {
+		t.Errorf("got %d reorgs, want 1", n)
+	}
+
+	// stored child 4 is canonical, only block 3 is stale
+	scanBlocks(t, idx, 4, 4)
+
+	err = idx.storeBlock(ctx, stale[0])
+	if err == nil || !strings.Contains(err.Error(), "not on the canonical chain") {
+		t.Fatalf("got %v, want block 3 not on the canonical chain", err)
+	}
+
+	if n := idx.reorgs.Load(); n != 1 {
+		t.Errorf("got %d reorgs, want 1", n)
+	}
+
+	scanBlocks(t, idx, 3, 3)
+
+	if got, want := scanned(store, 2, 4), []bool{true, true, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got blocks 2 to 4 scanned %v, want %v", got, want)
+	}
+}
+
+// TestReorgTip
+func TestReorgTip(t *testing.T) {
+	idx, backend, store := newTestIndexer(t, types.GenesisAlloc{})
+
+	follow(t, idx)
+
+	for i := 0; i < 3; i++ {
+		backend.Commit()
+	}
+	waitScanned(t, store, 0, 3)
+
+	// tip 3 replaced by another block 3
+	fork(t, backend, 2, 1)
+
+	ctx := context.Background()
+
+	header, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if header.Number.Int64() != 3 {
+		t.Fatalf("got head %d, want 3", header.Number)
+	}
+
+	waitFor(t, "the new tip", func() bool {
+		hash, err := store.GetBlockHash(ctx, 3)
+		return err == nil && hash == header.Hash().Hex()
+	})
+
+	if n := idx.reorgs.Load(); n != 1 {
+		t.Errorf("got %d reorgs, want 1", n)
+	}
+}
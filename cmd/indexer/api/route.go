@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/source"
 )
 
 // routes register routes
@@ -61,6 +64,18 @@ func (idx *Indexer) handleHealthChech(w http.ResponseWriter, r *http.Request) {
 	health := map[string]interface{}{
 		"version": Version,
 		"store":   "up",
+		"reorgs":  idx.reorgs.Load(),
+	}
+
+	// active endpoint & heads
+	if p, ok := idx.client.(*source.Pool); ok {
+		statuses := p.Status()
+		for _, s := range statuses {
+			if s.Active {
+				health["endpoint"] = s.Name
+			}
+		}
+		health["endpoints"] = statuses
 	}
 
 	if err := idx.store.Ping(); err != nil {
@@ -87,6 +102,7 @@ func (idx *Indexer) handleIndex(w http.ResponseWriter, r *http.Request) {
 
 	if authToken[0] != idx.conf.Indexer.Token {
 		idx.writer(w, http.StatusUnauthorized, fmt.Errorf("auth_token is required"))
+		return
 	}
 
 	scanRange, found := query["scan"]
@@ -102,11 +118,17 @@ func (idx *Indexer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// add block ids to jobs queue
-	go func(s, e int64) {
-		for i := s; i <= e; i++ {
-			idx.jobs <- i
-		}
-	}(start, end)
+	sr := &chain.ScanRange{
+		Start:  start,
+		End:    end,
+		Cursor: start,
+		Follow: end >= idx.head.Load(),
+	}
+
+	if err := idx.scanRange(sr); err != nil {
+		idx.writer(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	idx.writer(w, http.StatusOK, "command executed")
 }
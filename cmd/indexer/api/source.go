@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/source"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/twiny/ratelimit"
+)
+
+// dialPool dials every configured endpoint, `endpoint`
+// alone when `endpoints` is empty.
+func dialPool(conf *config.Config) (*source.Pool, error) {
+	endpoints := conf.Indexer.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = []config.Endpoint{{URL: conf.Indexer.Endpoint}}
+	}
+
+	var pool = make([]*source.Endpoint, 0, len(endpoints))
+
+	for _, e := range endpoints {
+		client, err := source.Dial(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", redact(e.URL), err)
+		}
+
+		limiter := e.Limiter
+		if limiter.Rate == 0 {
+			limiter = conf.Indexer.Limiter
+		}
+
+		var l *ratelimit.Limiter
+		if limiter.Rate > 0 {
+			l = ratelimit.NewLimiter(limiter.Rate, limiter.Duration)
+		}
+
+		pool = append(pool, &source.Endpoint{
+			Name:    redact(e.URL),
+			Source:  client,
+			Weight:  e.Weight,
+			Limiter: l,
+		})
+	}
+
+	lag := conf.Indexer.MaxLag
+	if lag <= 0 {
+		lag = maxLag
+	}
+
+	return source.NewPool(pool, lag)
+}
+
+// maxLag default blocks an endpoint may lag behind the others,
+// a block or two is usual between endpoints at the tip.
+const maxLag = 5
+
+// healthCheck default interval between endpoint checks
+const healthCheck = 15 * time.Second
+
+// watch checks the endpoints of the pool until the indexer stops.
+func (idx *Indexer) watch() {
+	p, ok := idx.client.(*source.Pool)
+	if !ok {
+		return
+	}
+
+	interval := idx.conf.Indexer.HealthCheck
+	if interval <= 0 {
+		interval = healthCheck
+	}
+
+	idx.wg.Add(1)
+	go func() {
+		defer idx.wg.Done()
+		p.Watch(idx.ctx, interval)
+	}()
+}
+
+// redact drops the path & query of endpoint, providers
+// put API keys there, e.g. https://mainnet.infura.io/v3/{api_key}
+func redact(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "invalid endpoint"
+	}
+
+	// e.g. an IPC path
+	if u.Host == "" {
+		return endpoint
+	}
+
+	return u.Scheme + "://" + u.Host
+}
+
+// checkTrace traces the block with hash, nodes often disable or
+// restrict the debug namespace even when the source could trace.
+func checkTrace(ctx context.Context, client source.ChainSource, hash common.Hash) error {
+	tracer, ok := client.(source.Tracer)
+	if p, isPool := client.(*source.Pool); !ok || isPool && !p.CanTrace() {
+		return fmt.Errorf("trace enabled but the chain source can not trace calls")
+	}
+
+	if _, err := tracer.TraceBlockCalls(ctx, hash); err != nil {
+		return fmt.Errorf("trace enabled but the chain source failed to trace block %s: %w", hash.Hex(), err)
+	}
+
+	return nil
+}
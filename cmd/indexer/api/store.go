@@ -2,14 +2,98 @@ package api
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/migrate"
+	"github.com/twiny/blockscan/service/inmemory"
+	"github.com/twiny/blockscan/service/postgres"
+	"github.com/twiny/blockscan/service/sqlite"
 )
 
 // StoreWriter
 type StoreWriter interface {
 	Ping() error
 	HasScanned(ctx context.Context, id int64) bool
-	SaveBlock(ctx context.Context, block *chain.Block) error
-	SaveTx(ctx context.Context, tx *chain.Tx) error
+	GetBlockHash(ctx context.Context, id int64) (string, error)
+	GetBlock(ctx context.Context, id int64) (*chain.Block, error)
+	GetTotalDifficulty(ctx context.Context, id int64) (string, error)
+	SetTotalDifficulty(ctx context.Context, id int64, td string) error
+	DeleteBlocks(ctx context.Context, i, j int64) error
+	GetGaps(ctx context.Context, i, j int64) ([]*chain.Gap, error)
+	SaveBlockBatch(ctx context.Context, b *chain.BlockBatch) error
+	//
+	GetBalance(ctx context.Context, address string, n int64) (*chain.Balance, error)
+	GetBalanceAddresses(ctx context.Context, i, j int64, limit int) ([]string, error)
+	//
+	HasToken(ctx context.Context, address string) bool
+	//
+	GetScanRanges(ctx context.Context) ([]*chain.ScanRange, error)
+	SaveScanRange(ctx context.Context, r *chain.ScanRange) error
+	UpdateScanRange(ctx context.Context, r *chain.ScanRange) error
+}
+
+// database a StoreWriter over a database connection.
+type database interface {
+	StoreWriter
+	Close() error
+}
+
+// versioned a store with schema migrations, the in-memory store has none.
+type versioned interface {
+	Migrator() (*migrate.Migrator, error)
+}
+
+// openStore opens the store selected by `store.driver`
+// & checks its schema is up to date.
+func openStore(conf *config.Config) (database, error) {
+	store, err := dialStore(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// refuse to run against a schema this build doesn't expect
+	if v, ok := store.(versioned); ok {
+		m, err := v.Migrator()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+
+		if err := m.Check(context.Background()); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("%w, run `indexer migrate up`", err)
+		}
+	}
+
+	return store, nil
+}
+
+// dialStore opens the store selected by `store.driver`.
+func dialStore(conf *config.Config) (database, error) {
+	var (
+		store database
+		err   error
+	)
+
+	switch conf.Store.Driver {
+	case "", "sqlite":
+		store, err = sqlite.NewSQLiteDB(conf.Store.Path)
+	case "postgres":
+		store, err = postgres.NewPostgresDB(conf.Store.DSN)
+	case "memory":
+		store, err = inmemory.NewInMemory(conf.Store.Snapshot)
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", conf.Store.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Ping(); err != nil {
+		return nil, err
+	}
+
+	return store, nil
 }
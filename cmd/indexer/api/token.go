@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/token"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tokenTransfers decodes ERC-20 transfers emitted by txs.
+func tokenTransfers(txs []*chain.Tx) []*chain.TokenTransfer {
+	var transfers = []*chain.TokenTransfer{}
+
+	for _, t := range txs {
+		if t.Receipt == nil {
+			continue
+		}
+
+		for _, l := range t.Receipt.Logs {
+			if transfer, ok := token.DecodeERC20Transfer(l); ok {
+				transfers = append(transfers, transfer)
+			}
+		}
+	}
+
+	return transfers
+}
+
+// nftTransfers decodes ERC-721 & ERC-1155 transfers emitted by txs.
+func nftTransfers(txs []*chain.Tx) []*chain.NFTTransfer {
+	var transfers = []*chain.NFTTransfer{}
+
+	for _, t := range txs {
+		if t.Receipt == nil {
+			continue
+		}
+
+		for _, l := range t.Receipt.Logs {
+			transfers = append(transfers, token.DecodeNFTTransfers(l)...)
+		}
+	}
+
+	return transfers
+}
+
+// fetchTokens the metadata of tokens not stored yet in transfers, saved
+// along with the block. Blocks fetched at once may fetch the same token,
+// the store keeps the first one saved & ignores the others.
+func (idx *Indexer) fetchTokens(ctx context.Context, transfers []*chain.TokenTransfer) []*chain.Token {
+	var (
+		seen   = map[string]bool{}
+		tokens = []*chain.Token{}
+	)
+
+	for _, t := range transfers {
+		if seen[t.Token] {
+			continue
+		}
+		seen[t.Token] = true
+
+		if idx.store.HasToken(ctx, t.Token) {
+			continue
+		}
+
+		tokens = append(tokens, token.Metadata(ctx, idx.client, common.HexToAddress(t.Token)))
+	}
+
+	return tokens
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/source"
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fetchInternalTxs traces the calls of block & flattens them,
+// nil when tracing is disabled.
+func (idx *Indexer) fetchInternalTxs(ctx context.Context, block *types.Block, txs []*chain.Tx) ([]*chain.InternalTx, error) {
+	if !idx.conf.Indexer.Trace || len(txs) == 0 {
+		return nil, nil
+	}
+
+	// checked by `newIndexer`
+	tracer := idx.client.(source.Tracer)
+
+	frames, err := tracer.TraceBlockCalls(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(frames) != len(txs) {
+		return nil, fmt.Errorf("block %d: %d traces for %d txs", block.Number(), len(frames), len(txs))
+	}
+
+	// traces are matched to txs by position
+	for i, f := range frames {
+		if f.TxHash != (common.Hash{}) && f.TxHash.Hex() != txs[i].Hash {
+			return nil, fmt.Errorf("block %d: trace %d of tx %s, want %s", block.Number(), i, f.TxHash.Hex(), txs[i].Hash)
+		}
+	}
+
+	var internal = []*chain.InternalTx{}
+	for i, f := range frames {
+		internal = append(internal, flattenCalls(txs[i], f)...)
+	}
+
+	return internal, nil
+}
+
+// flattenCalls the calls made under the root frame of t, depth first.
+func flattenCalls(t *chain.Tx, root *source.CallFrame) []*chain.InternalTx {
+	var (
+		calls = []*chain.InternalTx{}
+		walk  func(f *source.CallFrame, depth int)
+	)
+
+	walk = func(f *source.CallFrame, depth int) {
+		for _, c := range f.Calls {
+			value := "0"
+			if c.Value != nil {
+				value = c.Value.ToInt().String()
+			}
+
+			calls = append(calls, &chain.InternalTx{
+				TxHash:      t.Hash,
+				BlockNumber: t.BlockNumber,
+				Index:       len(calls),
+				Depth:       depth,
+				Type:        c.Type,
+				From:        c.From.Hex(),
+				To:          utils.AddrToHex(c.To),
+				Value:       value,
+				Error:       c.Error,
+			})
+
+			walk(c, depth+1)
+		}
+	}
+
+	walk(root, 1)
+
+	return calls
+}
@@ -16,7 +16,7 @@ func (idx *Indexer) parseScanQuery(s string) (start int64, end int64, err error)
 	switch {
 	case len(s) == 0:
 		start = 0
-		end = idx.head
+		end = idx.head.Load()
 		return
 	case len(parts) == 1:
 		start, err = strconv.ParseInt(parts[0], 10, 0)
@@ -24,7 +24,7 @@ func (idx *Indexer) parseScanQuery(s string) (start int64, end int64, err error)
 			return
 		}
 
-		end = idx.head
+		end = idx.head.Load()
 		return
 
 	case len(parts) == 2:
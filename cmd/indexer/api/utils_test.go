@@ -1,63 +1,161 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/migrate"
+	"github.com/twiny/blockscan/pkg/source/simulated"
+	"github.com/twiny/blockscan/service/sqlite"
+
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
-func TestParseScanQuery(t *testing.T) {
-	idx, err := NewIndexer("config/config.yaml")
+// newTestIndexer an indexer backed by a simulated chain & a temporary store.
+func newTestIndexer(t *testing.T, alloc types.GenesisAlloc) (*Indexer, *simulated.Backend, *sqlite.SQLite) {
+	t.Helper()
+
+	return newTestIndexerWith(t, alloc, nil)
+}
+
+// newTestIndexerWith newTestIndexer with configure applied to its config.
+func newTestIndexerWith(t *testing.T, alloc types.GenesisAlloc, configure func(conf *config.Config)) (*Indexer, *simulated.Backend, *sqlite.SQLite) {
+	t.Helper()
+
+	store, err := sqlite.NewSQLiteDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := store.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := simulated.NewBackend(alloc)
+
+	conf := &config.Config{}
+	conf.Indexer.Limiter.Rate = 100
+	conf.Indexer.Limiter.Duration = time.Second
+	conf.Indexer.Workers = 1
+	conf.Indexer.Timeout = 5 * time.Second
+
+	if configure != nil {
+		configure(conf)
+	}
+
+	idx, err := newIndexer(conf, store, backend)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	t.Cleanup(func() {
+		idx.done()
+		idx.wg.Wait()
+		backend.Close()
+		store.Close()
+	})
+
+	return idx, backend, store
+}
+
+func TestParseScanQuery(t *testing.T) {
+	idx, _, _ := newTestIndexer(t, types.GenesisAlloc{})
+
+	idx.head.Store(300)
+
 	tests := []struct {
 		name  string
 		query string
-		want  []any
+		start int64
+		end   int64
+		err   bool
 	}{
 		{
 			name:  "TestValidRange",
 			query: "100:200",
-			want:  []any{100, 200, nil},
+			start: 100,
+			end:   200,
 		},
 		{
 			name:  "TestBadRange",
 			query: "x:y",
-			// want:  []any{0, 0, err}, // invalid range error
+			err:   true,
+		},
+		{
+			name:  "TestToLatest",
+			query: "100",
+			start: 100,
+			end:   300,
 		},
 		{
-			// add test for range from id to latest
+			name:  "TestEmpty",
+			query: "",
+			start: 0,
+			end:   300,
+		},
+		{
+			name:  "TestTooManyParts",
+			query: "1:2:3",
+			err:   true,
 		},
 	}
 
 	for _, tc := range tests {
-		start, end, err := idx.parseScanQuery(tc.query)
-
-		s, ok := tc.want[0].(int64)
-		if !ok {
-			//
-		}
-		if start != s {
-			//
-		}
-
-		//
-
-		e, ok := tc.want[1].(int64)
-		if !ok {
-			//
-		}
-		if end != e {
-			//
-		}
-
-		er, ok := tc.want[2].(error)
-		if !ok {
-			//
-		}
-		if err != er {
-			//
-		}
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, err := idx.parseScanQuery(tc.query)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error for %q", tc.query)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if start != tc.start || end != tc.end {
+				t.Errorf("got %d:%d, want %d:%d", start, end, tc.start, tc.end)
+			}
+		})
+	}
+}
+
+// TestOpenStore refuses an unmigrated schema.
+func TestOpenStore(t *testing.T) {
+	conf := &config.Config{}
+	conf.Store.Path = t.TempDir()
+
+	if _, err := openStore(conf); !errors.Is(err, migrate.ErrOutOfDate) {
+		t.Fatalf("got %v, want ErrOutOfDate", err)
+	}
+
+	store, err := dialStore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := store.(versioned).Migrator()
+	if err != nil {
+		t.Fatal(err)
 	}
 
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = openStore(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/twiny/blockscan/cmd/indexer/api"
 
@@ -33,10 +35,97 @@ func main() {
 
 			return app.Start()
 		},
+		Commands: []*cli.Command{
+			{
+				Name:  "reconcile",
+				Usage: "spot-check derived balances against the chain source",
+				Flags: []cli.Flag{
+					&cli.Int64Flag{
+						Name:     "from",
+						Usage:    "compare balance changes after block `N`",
+						Required: true,
+					},
+					&cli.Int64Flag{
+						Name:     "to",
+						Usage:    "compare balance changes up to block `N`",
+						Required: true,
+					},
+					&cli.IntFlag{
+						Name:  "sample",
+						Usage: "`number` of random addresses active in the range to check",
+						Value: 20,
+					},
+					&cli.StringSliceFlag{
+						Name:  "address",
+						Usage: "`address` to check instead of a random sample",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return api.Reconcile(
+						c.String("config"),
+						c.Int64("from"),
+						c.Int64("to"),
+						c.Int("sample"),
+						c.StringSlice("address"),
+					)
+				},
+			},
+			{
+				Name:  "migrate",
+				Usage: "apply or revert versioned schema migrations",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "up",
+						Usage: "apply every pending migration",
+						Action: func(c *cli.Context) error {
+							return api.Migrate(c.String("config"), "up", 0)
+						},
+					},
+					{
+						Name:      "down",
+						Usage:     "revert the N most recent migrations",
+						ArgsUsage: "N",
+						Action: func(c *cli.Context) error {
+							n, err := intArg(c)
+							if err != nil {
+								return err
+							}
+							return api.Migrate(c.String("config"), "down", n)
+						},
+					},
+					{
+						Name:  "status",
+						Usage: "list migrations & whether they are applied",
+						Action: func(c *cli.Context) error {
+							return api.Migrate(c.String("config"), "status", 0)
+						},
+					},
+					{
+						Name:      "goto",
+						Usage:     "apply or revert migrations up to version V",
+						ArgsUsage: "V",
+						Action: func(c *cli.Context) error {
+							v, err := intArg(c)
+							if err != nil {
+								return err
+							}
+							return api.Migrate(c.String("config"), "goto", v)
+						},
+					},
+				},
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		log.Println(err)
-		return
+		log.Fatal(err)
+	}
+}
+
+// intArg the single integer argument of a command.
+func intArg(c *cli.Context) (int, error) {
+	if c.NArg() != 1 {
+		return 0, fmt.Errorf("%s expects 1 argument, got %d", c.Command.Name, c.NArg())
 	}
+	return strconv.Atoi(c.Args().First())
 }
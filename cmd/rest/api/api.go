@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	_ "embed"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -11,7 +12,6 @@ import (
 	"time"
 
 	"github.com/twiny/blockscan/pkg/config"
-	"github.com/twiny/blockscan/service/sqlite"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -41,20 +41,11 @@ func NewAPI(path string) (*API, error) {
 	mux := chi.NewRouter()
 
 	// db
-	store, err := sqlite.NewSQLiteDB(conf.Store.Path)
+	store, err := openStore(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := store.Ping(); err != nil {
-		return nil, err
-	}
-
-	//
-	if err := store.Migrate("up"); err != nil {
-		return nil, err
-	}
-
 	return &API{
 		conf: conf,
 		//
@@ -110,6 +101,12 @@ func (a *API) Shutdown() {
 		a.log.Println(err)
 	}
 
+	if c, ok := a.store.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			a.log.Println(err)
+		}
+	}
+
 	log.Println("goodbye.")
 	os.Exit(0)
 }
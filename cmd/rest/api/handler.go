@@ -1,12 +1,16 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/twiny/blockscan/pkg/chain"
+
 	"github.com/go-chi/chi/v5"
 )
 
@@ -20,6 +24,30 @@ func (a *API) notAllowed(w http.ResponseWriter, r *http.Request) {
 	a.writer(w, http.StatusMethodNotAllowed, "method not allowed")
 }
 
+// storeError responds 503 while the store is missing or locked,
+// 404 when nothing is found, 500 otherwise.
+func (a *API) storeError(w http.ResponseWriter, err error) {
+	if a.unavailable(err) {
+		a.log.Printf("store unavailable, %s", err.Error())
+		w.Header().Set("Retry-After", "1")
+		a.writer(w, http.StatusServiceUnavailable, "store unavailable, try again later")
+		return
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		a.writer(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	a.writer(w, http.StatusInternalServerError, err.Error())
+}
+
+// unavailable
+func (a *API) unavailable(err error) bool {
+	c, ok := a.store.(classifier)
+	return ok && c.Unavailable(err)
+}
+
 // // \\ \\
 
 // handleHealthChech
@@ -30,8 +58,8 @@ func (a *API) handleHealthChech(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := a.store.Ping(); err != nil {
-		health["database"] = "down"
-		a.writer(w, http.StatusInternalServerError, health)
+		health["store"] = "down"
+		a.writer(w, http.StatusServiceUnavailable, health)
 		return
 	}
 
@@ -85,7 +113,7 @@ func (a *API) handleGetLatestBlock(w http.ResponseWriter, r *http.Request) {
 	block, err := a.store.GetLatestBlock(ctx)
 	if err != nil {
 		a.log.Printf("err, get_latest_block, %s", err.Error())
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
@@ -111,7 +139,7 @@ func (a *API) handleGetBlock(w http.ResponseWriter, r *http.Request) {
 
 	block, err := a.store.GetBlock(ctx, num)
 	if err != nil {
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
@@ -130,7 +158,7 @@ func (a *API) handleGetStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := a.store.GetStats(ctx, 0, latest)
 	if err != nil {
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
@@ -151,7 +179,7 @@ func (a *API) handleGetRangeStats(w http.ResponseWriter, r *http.Request) {
 
 	stats, err := a.store.GetStats(ctx, start, end)
 	if err != nil {
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
@@ -164,7 +192,7 @@ func (a *API) handleGetLatestTx(w http.ResponseWriter, r *http.Request) {
 
 	tx, err := a.store.GetLatestTx(ctx)
 	if err != nil {
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
@@ -181,9 +209,500 @@ func (a *API) handleGetTx(w http.ResponseWriter, r *http.Request) {
 
 	tx, err := a.store.GetTx(ctx, hash)
 	if err != nil {
-		a.writer(w, http.StatusInternalServerError, err.Error())
+		a.storeError(w, err)
 		return
 	}
 
 	a.writer(w, http.StatusOK, tx)
 }
+
+// handleGetInternalTxs - calls traced in a transaction
+func (a *API) handleGetInternalTxs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	hash := chi.URLParam(r, "hash")
+
+	txs, err := a.store.GetInternalTxs(ctx, hash)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, txs)
+}
+
+// handleGetAddressInternalTxs - ?range=100:200&limit=100
+func (a *API) handleGetAddressInternalTxs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	txs, err := a.store.GetAddressInternalTxs(ctx, address, start, end, limit)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, txs)
+}
+
+// handleGetGaps - returns ranges of missing blocks
+func (a *API) handleGetGaps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	interval := chi.URLParam(r, "range")
+
+	start, end, err := parseRange(interval)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	gaps, err := a.store.GetGaps(ctx, start, end)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, gaps)
+}
+
+// handleGetLogs - ?address=0xa,0xb&topic0=0x1,0x2&topic1=&range=100:200&limit=100
+func (a *API) handleGetLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := &chain.LogFilter{
+		Limit: limit,
+	}
+
+	filter.FromBlock, filter.ToBlock, err = a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Addresses, err = parseAddresses(query.Get("address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for i := range filter.Topics {
+		filter.Topics[i], err = parseTopics(query.Get(fmt.Sprintf("topic%d", i)))
+		if err != nil {
+			a.writer(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	logs, err := a.store.GetLogs(ctx, filter)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, logs)
+}
+
+// handleGetToken
+func (a *API) handleGetToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, err := a.store.GetToken(ctx, address)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, token)
+}
+
+// handleGetContract
+func (a *API) handleGetContract(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	contract, err := a.store.GetContract(ctx, address)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, contract)
+}
+
+// handleGetDeployerContracts - contracts deployed by an address ?range=100:200&limit=100
+func (a *API) handleGetDeployerContracts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	contracts, err := a.store.GetDeployerContracts(ctx, address, start, end, limit)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, contracts)
+}
+
+// handleGetTokenTransfers - ?range=100:200&limit=100
+func (a *API) handleGetTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, err := a.store.GetTokenTransfers(ctx, address, start, end, limit)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, transfers)
+}
+
+// handleGetAddressTxs - ?direction=in|out|all&range=100:200&limit=100&cursor=150:3
+func (a *API) handleGetAddressTxs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := &chain.AddressTxFilter{
+		Address: address,
+	}
+
+	filter.Direction, err = parseDirection(query.Get("direction"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.FromBlock, filter.ToBlock, err = a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Cursor, err = parseCursor(query.Get("cursor"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Limit, err = parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	txs, err := a.store.GetAddressTxs(ctx, filter)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	page := &chain.TxPage{
+		Txs: txs,
+	}
+
+	// a full page may be followed by more
+	if len(txs) == filter.Limit {
+		page.Next = formatCursor(txs[len(txs)-1])
+	}
+
+	a.writer(w, http.StatusOK, page)
+}
+
+// handleGetBalance - ?block=N, defaults to the latest indexed block.
+func (a *API) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var n int64
+	if s := r.URL.Query().Get("block"); s != "" {
+		n, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			a.writer(w, http.StatusBadRequest, "block must be a positive number")
+			return
+		}
+	} else {
+		block, err := a.store.GetLatestBlock(ctx)
+		if err != nil && a.unavailable(err) {
+			a.storeError(w, err)
+			return
+		}
+		if err != nil {
+			a.writer(w, http.StatusNotFound, "no block indexed")
+			return
+		}
+		n = block.Number
+	}
+
+	balance, err := a.store.GetBalance(ctx, address, n)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, balance)
+}
+
+// handleGetAddressWithdrawals - ?range=100:200&limit=100
+func (a *API) handleGetAddressWithdrawals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	withdrawals, err := a.store.GetAddressWithdrawals(ctx, address, start, end, limit)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, withdrawals)
+}
+
+// handleGetAddressTokenTransfers - ?range=100:200&limit=100
+func (a *API) handleGetAddressTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, err := a.store.GetAddressTokenTransfers(ctx, address, start, end, limit)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, transfers)
+}
+
+// handleGetNFTTransfers - transfers of a collection, or of a
+// token id when present. ?range=100:200&limit=100
+func (a *API) handleGetNFTTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	collection, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var tokenID string
+	if id := chi.URLParam(r, "id"); id != "" {
+		tokenID, err = parseTokenID(id)
+		if err != nil {
+			a.writer(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	filter := &chain.NFTTransferFilter{
+		Collection: collection,
+		TokenID:    tokenID,
+	}
+
+	filter.FromBlock, filter.ToBlock, err = a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Limit, err = parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, err := a.store.GetNFTTransfers(ctx, filter)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, transfers)
+}
+
+// handleGetNFTOwner - current holders of a token id
+func (a *API) handleGetNFTOwner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tokenID, err := parseTokenID(chi.URLParam(r, "id"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	owners, err := a.store.GetNFTOwners(ctx, collection, tokenID)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, owners)
+}
+
+// handleGetAddressNFTTransfers - ?range=100:200&limit=100
+func (a *API) handleGetAddressNFTTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	address, err := parseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := &chain.NFTTransferFilter{
+		Address: address,
+	}
+
+	filter.FromBlock, filter.ToBlock, err = a.queryRange(ctx, query)
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Limit, err = parseLimit(query.Get("limit"))
+	if err != nil {
+		a.writer(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, err := a.store.GetNFTTransfers(ctx, filter)
+	if err != nil {
+		a.storeError(w, err)
+		return
+	}
+
+	a.writer(w, http.StatusOK, transfers)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/twiny/blockscan/service/inmemory"
+	"github.com/twiny/blockscan/service/sqlite"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestStoreUnavailable
+func TestStoreUnavailable(t *testing.T) {
+	// the indexer hasn't created the store yet
+	store, err := sqlite.NewSQLiteReader(t.TempDir(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	a := &API{
+		store: store,
+		log:   log.Default(),
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{name: "TestLatestBlock", handler: a.handleGetLatestBlock},
+		{name: "TestHealth", handler: a.handleHealthChech},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tc.handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("got status %d, want 503", w.Code)
+			}
+		})
+	}
+}
+
+// TestStoreNotFound
+func TestStoreNotFound(t *testing.T) {
+	store, err := inmemory.NewInMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &API{
+		store: store,
+		log:   log.Default(),
+	}
+
+	mux := chi.NewRouter()
+	mux.Get("/block/{id}", a.handleGetBlock)
+	mux.Get("/tx/{hash}", a.handleGetTx)
+	mux.Get("/token/{address}", a.handleGetToken)
+	mux.Get("/contract/{address}", a.handleGetContract)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "TestBlock", path: "/block/7"},
+		{name: "TestTx", path: "/tx/0x7777777777777777777777777777777777777777777777777777777777777777"},
+		{name: "TestToken", path: "/token/0x00000000000000000000000000000000000000aa"},
+		{name: "TestContract", path: "/contract/0x00000000000000000000000000000000000000aa"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("got status %d, want 404", w.Code)
+			}
+		})
+	}
+}
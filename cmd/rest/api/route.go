@@ -36,6 +36,34 @@ func (a *API) routes() {
 		//
 		r.Get("/tx", a.handleGetLatestTx)
 		r.Get("/tx/{hash}", a.handleGetTx)
+		r.Get("/tx/{hash}/internal", a.handleGetInternalTxs)
+
+		//
+		r.Get("/logs", a.handleGetLogs)
+
+		//
+		r.Get("/token/{address}", a.handleGetToken)
+		r.Get("/token/{address}/transfers", a.handleGetTokenTransfers)
+
+		//
+		r.Get("/nft/{address}/transfers", a.handleGetNFTTransfers)
+		r.Get("/nft/{address}/{id}/transfers", a.handleGetNFTTransfers)
+		r.Get("/nft/{address}/{id}/owner", a.handleGetNFTOwner)
+
+		//
+		r.Get("/address/{address}/txs", a.handleGetAddressTxs)
+		r.Get("/address/{address}/internal-txs", a.handleGetAddressInternalTxs)
+		r.Get("/address/{address}/balance", a.handleGetBalance)
+		r.Get("/address/{address}/contracts", a.handleGetDeployerContracts)
+		r.Get("/address/{address}/withdrawals", a.handleGetAddressWithdrawals)
+		r.Get("/address/{address}/token-transfers", a.handleGetAddressTokenTransfers)
+		r.Get("/address/{address}/nft-transfers", a.handleGetAddressNFTTransfers)
+
+		//
+		r.Get("/contract/{address}", a.handleGetContract)
+
+		//
+		r.Get("/gaps/{range}", a.handleGetGaps)
 	})
 }
 
@@ -2,8 +2,15 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"log"
 
 	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/config"
+	"github.com/twiny/blockscan/pkg/migrate"
+	"github.com/twiny/blockscan/service/inmemory"
+	"github.com/twiny/blockscan/service/postgres"
+	"github.com/twiny/blockscan/service/sqlite"
 )
 
 // StoreReader
@@ -15,6 +22,90 @@ type StoreReader interface {
 	//
 	GetLatestTx(ctx context.Context) (*chain.Tx, error)
 	GetTx(ctx context.Context, hash string) (*chain.Tx, error)
+	GetAddressTxs(ctx context.Context, f *chain.AddressTxFilter) ([]*chain.Tx, error)
+	GetInternalTxs(ctx context.Context, hash string) ([]*chain.InternalTx, error)
+	GetAddressInternalTxs(ctx context.Context, address string, i, j int64, limit int) ([]*chain.InternalTx, error)
+	GetBalance(ctx context.Context, address string, n int64) (*chain.Balance, error)
+	GetAddressWithdrawals(ctx context.Context, address string, i, j int64, limit int) ([]*chain.Withdrawal, error)
+	//
+	GetLogs(ctx context.Context, f *chain.LogFilter) ([]*chain.Log, error)
+	//
+	GetToken(ctx context.Context, address string) (*chain.Token, error)
+	GetTokenTransfers(ctx context.Context, token string, i, j int64, limit int) ([]*chain.TokenTransfer, error)
+	GetAddressTokenTransfers(ctx context.Context, address string, i, j int64, limit int) ([]*chain.TokenTransfer, error)
+	//
+	GetNFTTransfers(ctx context.Context, f *chain.NFTTransferFilter) ([]*chain.NFTTransfer, error)
+	GetNFTOwners(ctx context.Context, collection, tokenID string) ([]*chain.NFTOwner, error)
+	//
+	GetContract(ctx context.Context, address string) (*chain.Contract, error)
+	GetDeployerContracts(ctx context.Context, deployer string, i, j int64, limit int) ([]*chain.Contract, error)
 	//
 	GetStats(ctx context.Context, i, j int64) (*chain.Stats, error)
+	//
+	GetGaps(ctx context.Context, i, j int64) ([]*chain.Gap, error)
+}
+
+// database a StoreReader over a database connection.
+type database interface {
+	StoreReader
+	Close() error
+}
+
+// versioned a store with schema migrations, the in-memory store has none.
+type versioned interface {
+	Migrator() (*migrate.Migrator, error)
+}
+
+// classifier a store that tells a missing or locked database from other errors.
+type classifier interface {
+	Unavailable(err error) bool
+}
+
+// openStore opens the store selected by `store.driver` read-only,
+// the schema is checked but never migrated, that's up to the indexer.
+func openStore(conf *config.Config) (database, error) {
+	var (
+		store database
+		err   error
+	)
+
+	switch conf.Store.Driver {
+	case "", "sqlite":
+		store, err = sqlite.NewSQLiteReader(conf.Store.Path, conf.Store.ReadConns)
+	case "postgres":
+		store, err = postgres.NewPostgresReader(conf.Store.DSN, conf.Store.ReadConns)
+	case "memory":
+		// a copy of the snapshot, blocks indexed since are not served
+		log.Printf("memory store, serving snapshot %q as loaded now, it is never refreshed", conf.Store.Snapshot)
+		store, err = inmemory.NewInMemory(conf.Store.Snapshot)
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", conf.Store.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// refuse to run against a schema this build doesn't expect
+	if v, ok := store.(versioned); ok {
+		m, err := v.Migrator()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+
+		err = m.Check(context.Background())
+
+		// start anyway & respond 503 until the indexer creates it
+		if c, ok := store.(classifier); ok && err != nil && c.Unavailable(err) {
+			log.Printf("store unavailable, schema not checked: %s", err.Error())
+			return store, nil
+		}
+
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("%w, run `indexer migrate up`", err)
+		}
+	}
+
+	return store, nil
 }
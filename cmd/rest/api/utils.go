@@ -1,9 +1,17 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"net/url"
 	"strconv"
 	"strings"
+
+	"github.com/twiny/blockscan/pkg/chain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // parseRange
@@ -34,3 +42,141 @@ func parseRange(s string) (start int64, end int64, err error) {
 
 	return
 }
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// parseLimit - empty returns the default limit
+func parseLimit(s string) (int, error) {
+	if s == "" {
+		return defaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit <= 0 || limit > maxLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+
+	return limit, nil
+}
+
+// queryRange parses the `range=start:end` query parameter,
+// defaults to all indexed blocks.
+func (a *API) queryRange(ctx context.Context, query url.Values) (start int64, end int64, err error) {
+	if interval := query.Get("range"); interval != "" {
+		return parseRange(interval)
+	}
+
+	if block, err := a.store.GetLatestBlock(ctx); err == nil {
+		end = block.Number
+	}
+
+	return
+}
+
+// parseAddress returns an address in checksum format, as stored.
+func parseAddress(s string) (string, error) {
+	if !common.IsHexAddress(s) {
+		return "", fmt.Errorf("invalid address %q", s)
+	}
+
+	return common.HexToAddress(s).Hex(), nil
+}
+
+// parseAddresses comma separated list of addresses, in checksum format.
+func parseAddresses(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var addresses = []string{}
+	for _, part := range strings.Split(s, ",") {
+		address, err := parseAddress(part)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// parseTopics comma separated list of 32 bytes hex topics.
+func parseTopics(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var topics = []string{}
+	for _, part := range strings.Split(s, ",") {
+		b, err := hexutil.Decode(part)
+		if err != nil || len(b) != common.HashLength {
+			return nil, fmt.Errorf("invalid topic %q", part)
+		}
+
+		topics = append(topics, common.BytesToHash(b).Hex())
+	}
+
+	return topics, nil
+}
+
+// parseTokenID decimal or 0x prefixed hex token id, returned in decimal as stored.
+func parseTokenID(s string) (string, error) {
+	id, ok := new(big.Int).SetString(s, 0)
+	if !ok || id.Sign() < 0 {
+		return "", fmt.Errorf("invalid token id %q", s)
+	}
+
+	return id.String(), nil
+}
+
+// parseDirection in, out or all, empty returns all.
+func parseDirection(s string) (string, error) {
+	switch s {
+	case "":
+		return chain.DirectionAll, nil
+	case chain.DirectionIn, chain.DirectionOut, chain.DirectionAll:
+		return s, nil
+	default:
+		return "", fmt.Errorf("direction must be one of in, out or all")
+	}
+}
+
+// parseCursor `block:order` of the last tx of the previous page, empty returns nil.
+func parseCursor(s string) (*chain.TxCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("cursor must be in format block:order")
+	}
+
+	block, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || block < 0 {
+		return nil, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	order, err := strconv.Atoi(parts[1])
+	if err != nil || order < 0 {
+		return nil, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	return &chain.TxCursor{
+		BlockNumber: block,
+		Order:       order,
+	}, nil
+}
+
+// formatCursor
+func formatCursor(t *chain.Tx) string {
+	return fmt.Sprintf("%d:%d", t.BlockNumber, t.Order)
+}
@@ -0,0 +1,31 @@
+package chain
+
+// Transfer directions relative to an address
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+	DirectionAll = "all"
+)
+
+// TxCursor position of a transaction in the chain, pages
+// resume strictly before it (newest first).
+type TxCursor struct {
+	BlockNumber int64
+	Order       int
+}
+
+// AddressTxFilter
+type AddressTxFilter struct {
+	Address   string
+	Direction string // in, out or all
+	FromBlock int64
+	ToBlock   int64
+	Cursor    *TxCursor // nil starts from the newest tx
+	Limit     int
+}
+
+// TxPage a page of transactions, `Next` is empty on the last page.
+type TxPage struct {
+	Txs  []*Tx  `json:"txs"`
+	Next string `json:"next,omitempty"`
+}
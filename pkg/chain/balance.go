@@ -0,0 +1,36 @@
+package chain
+
+import (
+	"encoding/json"
+
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// BalanceDelta net change of an address native balance in a block.
+type BalanceDelta struct {
+	BlockNumber int64
+	Address     string
+	Delta       string // wei, base 10, negative when spent
+}
+
+// Balance native balance of an address at a block,
+// derived from the balance deltas of indexed blocks.
+type Balance struct {
+	Address     string `json:"address"`
+	BlockNumber int64  `json:"block_number"`
+	Balance     string `json:"balance"` // wei, base 10
+}
+
+// MarshalJSON renders the balance in ether along with wei.
+func (b Balance) MarshalJSON() ([]byte, error) {
+	type balance Balance
+	return json.Marshal(struct {
+		balance
+		BalanceEther decimal.Decimal `json:"balance_ether"`
+	}{
+		balance:      balance(b),
+		BalanceEther: utils.ToDecimal(b.Balance, 18),
+	})
+}
@@ -0,0 +1,14 @@
+package chain
+
+// BlockBatch a block along with everything indexed from it,
+// written to the store at once.
+type BlockBatch struct {
+	Block          *Block // including withdrawals
+	Txs            []*Tx  // including receipts & logs
+	InternalTxs    []*InternalTx
+	Contracts      []*Contract
+	Tokens         []*Token // metadata of tokens not saved before
+	TokenTransfers []*TokenTransfer
+	NFTTransfers   []*NFTTransfer
+	BalanceDeltas  []*BalanceDelta
+}
@@ -4,9 +4,21 @@ import "time"
 
 // Block
 type Block struct {
-	Number    int64     `json:"number"`
-	Hash      string    `json:"hash"`
-	Timestamp time.Time `json:"timestamp"` // timestamp when the block was mined
-	TxCount   uint      `json:"tx_count"`
-	Txs       []string  `json:"txs"` // array of transactions hash
+	Number          int64         `json:"number"`
+	Hash            string        `json:"hash"`
+	ParentHash      string        `json:"parent_hash"`
+	Miner           string        `json:"miner"`     // coinbase
+	Timestamp       time.Time     `json:"timestamp"` // timestamp when the block was mined
+	GasLimit        uint64        `json:"gas_limit"`
+	GasUsed         uint64        `json:"gas_used"`
+	BaseFee         string        `json:"base_fee_per_gas,omitempty"` // wei, since London
+	Difficulty      string        `json:"difficulty"`
+	TotalDifficulty string        `json:"total_difficulty,omitempty"` // empty until the parent is indexed
+	ExtraData       string        `json:"extra_data"`                 // hex encoded
+	Size            uint64        `json:"size"`                       // in bytes
+	StateRoot       string        `json:"state_root"`
+	WithdrawalsRoot string        `json:"withdrawals_root,omitempty"` // since Shanghai
+	TxCount         uint          `json:"tx_count"`
+	Txs             []string      `json:"txs"` // array of transactions hash
+	Withdrawals     []*Withdrawal `json:"withdrawals,omitempty"`
 }
@@ -0,0 +1,10 @@
+package chain
+
+// Contract a contract deployed by a transaction
+type Contract struct {
+	Address     string `json:"address"`
+	Deployer    string `json:"deployer"`
+	TxHash      string `json:"tx_hash"`
+	BlockNumber int64  `json:"block_number"`
+	CodeHash    string `json:"code_hash,omitempty"` // keccak256 of the runtime bytecode
+}
@@ -0,0 +1,7 @@
+package chain
+
+// Gap a range of missing blocks [From, To]
+type Gap struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
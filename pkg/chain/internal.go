@@ -0,0 +1,14 @@
+package chain
+
+// InternalTx a call made by a contract during a transaction, from its trace
+type InternalTx struct {
+	TxHash      string `json:"tx_hash"`
+	BlockNumber int64  `json:"block_number"`
+	Index       int    `json:"index"` // position of the call in the trace, depth first
+	Depth       int    `json:"depth"` // 1 for calls made by the called contract
+	Type        string `json:"type"`  // CALL, STATICCALL, DELEGATECALL, CREATE ...
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"` // wei, base 10
+	Error       string `json:"error,omitempty"`
+}
@@ -0,0 +1,39 @@
+package chain
+
+// NFT standards
+const (
+	ERC721  = "erc721"
+	ERC1155 = "erc1155"
+)
+
+// NFTTransfer an ERC-721 `Transfer` or an ERC-1155
+// `TransferSingle`/`TransferBatch` event
+type NFTTransfer struct {
+	TxHash      string `json:"tx_hash"`
+	BlockNumber int64  `json:"block_number"`
+	LogIndex    uint   `json:"log_index"`
+	BatchIndex  int    `json:"batch_index"` // position in a `TransferBatch`, 0 otherwise
+	Standard    string `json:"standard"`
+	Collection  string `json:"collection"` // token contract address
+	Operator    string `json:"operator,omitempty"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	TokenID     string `json:"token_id"`
+	Quantity    string `json:"quantity"` // always 1 for ERC-721
+}
+
+// NFTTransferFilter empty fields match anything.
+type NFTTransferFilter struct {
+	Collection string
+	TokenID    string
+	Address    string // from or to
+	FromBlock  int64
+	ToBlock    int64
+	Limit      int
+}
+
+// NFTOwner current holder of a token, derived from its transfers.
+type NFTOwner struct {
+	Address  string `json:"address"`
+	Quantity string `json:"quantity"`
+}
@@ -0,0 +1,35 @@
+package chain
+
+// Receipt
+type Receipt struct {
+	TxHash            string `json:"tx_hash"`
+	BlockNumber       int64  `json:"block_number"`
+	Status            uint64 `json:"status"`         // 1 success, 0 failure
+	PostState         string `json:"root,omitempty"` // state root, pre-Byzantium receipts carry it instead of a status
+	GasUsed           uint64 `json:"gas_used"`
+	CumulativeGasUsed uint64 `json:"cumulative_gas_used"`
+	EffectiveGasPrice string `json:"effective_gas_price"`        // in wei
+	ContractAddress   string `json:"contract_address,omitempty"` // set on contract creation
+	BlobGasUsed       uint64 `json:"blob_gas_used,omitempty"`    // blob transactions only
+	BlobGasPrice      string `json:"blob_gas_price,omitempty"`   // wei, blob transactions only
+	Logs              []*Log `json:"logs"`
+}
+
+// Log an event emitted by a transaction
+type Log struct {
+	TxHash      string   `json:"tx_hash"`
+	BlockNumber int64    `json:"block_number"`
+	Index       uint     `json:"log_index"` // index of the log in the block
+	Address     string   `json:"address"`   // emitting contract
+	Topics      []string `json:"topics"`
+	Data        string   `json:"data"` // hex encoded
+}
+
+// LogFilter `eth_getLogs` style filter, empty fields match anything.
+type LogFilter struct {
+	FromBlock int64
+	ToBlock   int64
+	Addresses []string    // any of the addresses
+	Topics    [4][]string // any of the topics at each position
+	Limit     int
+}
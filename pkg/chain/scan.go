@@ -0,0 +1,12 @@
+package chain
+
+// ScanRange a requested range of blocks to index, persisted
+// so pending work can be resumed after a restart.
+type ScanRange struct {
+	ID     int64 `json:"id"`
+	Start  int64 `json:"start"`
+	End    int64 `json:"end"`
+	Cursor int64 `json:"cursor"` // next block id to enqueue
+	Follow bool  `json:"follow"` // keep following the chain head once reached
+	Done   bool  `json:"done"`
+}
@@ -1,7 +1,27 @@
 package chain
 
+import (
+	"encoding/json"
+
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
 // Stats
 type Stats struct {
-	Txs         []string `json:"txs"` // array of transactions hash
-	TotalAmount float64  `json:"total_amount"`
+	Txs         []string `json:"txs"`          // array of transactions hash
+	TotalAmount string   `json:"total_amount"` // wei, base 10
+}
+
+// MarshalJSON renders the total amount in ether along with wei.
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type stats Stats
+	return json.Marshal(struct {
+		stats
+		TotalAmountEther decimal.Decimal `json:"total_amount_ether"`
+	}{
+		stats:            stats(s),
+		TotalAmountEther: utils.ToDecimal(s.TotalAmount, 18),
+	})
 }
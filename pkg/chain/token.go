@@ -0,0 +1,20 @@
+package chain
+
+// Token ERC-20 token metadata
+type Token struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// TokenTransfer an ERC-20 `Transfer` event
+type TokenTransfer struct {
+	TxHash      string `json:"tx_hash"`
+	BlockNumber int64  `json:"block_number"`
+	LogIndex    uint   `json:"log_index"`
+	Token       string `json:"token"` // token contract address
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"` // in token base units
+}
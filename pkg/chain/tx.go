@@ -1,15 +1,52 @@
 package chain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
 
 // Tx
 type Tx struct {
 	Hash        string    `json:"hash"`
 	BlockNumber int64     `json:"block_number"`
 	From        string    `json:"from"`
-	To          string    `json:"to"`
-	Amount      int64     `json:"amount"`
+	To          string    `json:"to,omitempty"` // empty on contract creation
+	Amount      string    `json:"amount"`       // wei, base 10
 	Nonce       uint64    `json:"nonce"`
 	Timestamp   time.Time `json:"timestamp"` // timestamp when the transaction was mined
 	Order       int       `json:"order"`     // used to keep same order of transaction
+	//
+	Type                 uint8          `json:"type"` // 0 legacy, 1 EIP-2930, 2 EIP-1559, 3 EIP-4844
+	GasLimit             uint64         `json:"gas_limit"`
+	GasPrice             string         `json:"gas_price"`                          // wei, the fee cap for EIP-1559 transactions
+	MaxFeePerGas         string         `json:"max_fee_per_gas,omitempty"`          // wei, since EIP-1559
+	MaxPriorityFeePerGas string         `json:"max_priority_fee_per_gas,omitempty"` // wei, since EIP-1559
+	Input                string         `json:"input"`                              // hex encoded
+	AccessList           []*AccessTuple `json:"access_list,omitempty"`
+	MaxFeePerBlobGas     string         `json:"max_fee_per_blob_gas,omitempty"` // wei, blob transactions only
+	BlobHashes           []string       `json:"blob_versioned_hashes,omitempty"`
+	//
+	Receipt *Receipt `json:"receipt,omitempty"`
+}
+
+// AccessTuple an address & storage keys a transaction plans to access
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storage_keys"`
+}
+
+// MarshalJSON renders the amount in ether along with wei.
+func (t Tx) MarshalJSON() ([]byte, error) {
+	type tx Tx
+	return json.Marshal(struct {
+		tx
+		AmountEther decimal.Decimal `json:"amount_ether"`
+	}{
+		tx:          tx(t),
+		AmountEther: utils.ToDecimal(t.Amount, 18),
+	})
 }
@@ -0,0 +1,10 @@
+package chain
+
+// Withdrawal a validator withdrawal from the beacon chain, since Shanghai
+type Withdrawal struct {
+	Index          uint64 `json:"index"`
+	BlockNumber    int64  `json:"block_number"`
+	ValidatorIndex uint64 `json:"validator_index"`
+	Address        string `json:"address"`
+	Amount         uint64 `json:"amount"` // in gwei
+}
@@ -20,20 +20,45 @@ type Config struct {
 		Host     string `yaml:"host"`
 		Token    string `yaml:"token"`
 		Endpoint string `yaml:"endpoint"`
-		Limiter  struct {
-			Rate     int           `yaml:"rate"`
-			Duration time.Duration `yaml:"duration"`
-		} `yaml:"limiter"`
-		Workers int           `yaml:"workers"`
-		Timeout time.Duration `yaml:"timeout"`
+		// Endpoints replace Endpoint, calls are spread over them by weight
+		Endpoints   []Endpoint    `yaml:"endpoints"`
+		HealthCheck time.Duration `yaml:"health_check"` // interval between endpoint checks, default 15s
+		MaxLag      int64         `yaml:"max_lag"`      // blocks an endpoint may lag behind the others, default 5
+		Poll        time.Duration `yaml:"poll"`         // interval between latest block polls without a head subscription, default 5s
+		Limiter     Limiter       `yaml:"limiter"`      // per endpoint
+		Workers     int           `yaml:"workers"`      // goroutines fetching blocks from the endpoints
+		Writers     int           `yaml:"writers"`      // goroutines writing fetched blocks to the store, default 1
+		Batch       int           `yaml:"batch"`        // blocks fetched per JSON-RPC batch, default 1
+		Timeout     time.Duration `yaml:"timeout"`
+		ReorgDepth  int64         `yaml:"reorg_depth"` // max blocks to walk back on a reorg
+		Backfill    time.Duration `yaml:"backfill"`    // interval between gap backfills, 0 disables it
+		Trace       bool          `yaml:"trace"`       // index internal txs, needs the node's debug namespace
 	} `yaml:"indexer"`
 
 	// Store
 	Store struct {
-		Path string `yaml:"path"`
+		Driver   string `yaml:"driver"`   // sqlite (default), postgres or memory
+		Path     string `yaml:"path"`     // sqlite directory
+		DSN      string `yaml:"dsn"`      // postgres connection string
+		Snapshot string `yaml:"snapshot"` // memory snapshot file, loaded on start & written on shutdown
+		// ReadConns caps the read-only connections of the rest service, 0 for the number of CPUs
+		ReadConns int `yaml:"read_conns"`
 	} `yaml:"store"`
 }
 
+// Endpoint a chain source of the indexer
+type Endpoint struct {
+	URL     string  `yaml:"url"`
+	Weight  int     `yaml:"weight"`  // share of the calls, default 1
+	Limiter Limiter `yaml:"limiter"` // defaults to the indexer limiter
+}
+
+// Limiter requests allowed per duration
+type Limiter struct {
+	Rate     int           `yaml:"rate"`
+	Duration time.Duration `yaml:"duration"`
+}
+
 // ParseConfig
 func ParseConfig(filename string) (*Config, error) {
 	data, err := ioutil.ReadFile(filename)
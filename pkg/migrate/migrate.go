@@ -0,0 +1,412 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrOutOfDate the schema is behind (or ahead of) the migrations of this build.
+	ErrOutOfDate = errors.New("schema out of date")
+
+	// ErrUntracked a table a pending migration creates already exists,
+	// e.g. in a database created by an unversioned build other than
+	// the one the first migration describes.
+	ErrUntracked = errors.New("schema has untracked tables")
+)
+
+// migration file names: 0001_init.up.sql, 0001_init.down.sql
+var filename = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// createTable the table names of an up step
+var createTable = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+
+// Migration a numbered schema change with its reverse step.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status of a migration, AppliedAt is zero when pending.
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations in version order & records them
+// in the `schema_migrations` table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration // ascending version
+}
+
+// New reads the migrations at the root of fsys.
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var versions = map[int]*Migration{}
+
+	for _, e := range entries {
+		match := filename.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", e.Name())
+		}
+
+		m, found := versions[version]
+		if !found {
+			m = &Migration{Version: version, Name: match[2]}
+			versions[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %s: version %d is already %s", e.Name(), version, m.Name)
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		switch match[3] {
+		case "up":
+			m.Up = string(body)
+		case "down":
+			m.Down = string(body)
+		}
+	}
+
+	var migrations = make([]*Migration, 0, len(versions))
+
+	for _, m := range versions {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: missing up or down step", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest the highest version known to this build.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version the highest applied version, 0 on an empty schema.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+// Status every known migration & when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses = make([]*Status, 0, len(m.migrations))
+
+	for _, mg := range m.migrations {
+		statuses = append(statuses, &Status{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			AppliedAt: applied[mg.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+// Check returns ErrOutOfDate unless every migration is applied.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.known(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, mg := range m.migrations {
+		if _, found := applied[mg.Version]; !found {
+			return fmt.Errorf("%w: version %d_%s is pending", ErrOutOfDate, mg.Version, mg.Name)
+		}
+	}
+
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.Goto(ctx, m.Latest())
+}
+
+// Down reverts the n most recent migrations.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	applied, err := m.known(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n < 0 || n > len(applied) {
+		return fmt.Errorf("cannot revert %d of %d applied migrations", n, len(applied))
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && n > 0; i-- {
+		mg := m.migrations[i]
+		if _, found := applied[mg.Version]; !found {
+			continue
+		}
+
+		if err := m.revert(ctx, mg); err != nil {
+			return err
+		}
+		n--
+	}
+
+	return nil
+}
+
+// Goto applies or reverts migrations until version is the latest applied.
+func (m *Migrator) Goto(ctx context.Context, version int) error {
+	if version < 0 || version > m.Latest() {
+		return fmt.Errorf("unknown version %d, latest is %d", version, m.Latest())
+	}
+
+	applied, err := m.known(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 && version > 0 && m.baseline(ctx) {
+		if err := m.adopt(ctx, m.migrations[0]); err != nil {
+			return err
+		}
+		applied[m.migrations[0].Version] = time.Now()
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if _, found := applied[mg.Version]; found && mg.Version > version {
+			if err := m.revert(ctx, mg); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, mg := range m.migrations {
+		if _, found := applied[mg.Version]; !found && mg.Version <= version {
+			if err := m.apply(ctx, mg); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// known the applied versions, failing if any of them is newer than
+// this build since it can't be reverted from here.
+func (m *Migrator) known(ctx context.Context) (map[int]time.Time, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for v := range applied {
+		if v > m.Latest() {
+			return nil, fmt.Errorf("%w: version %d is newer than this build (%d)", ErrOutOfDate, v, m.Latest())
+		}
+	}
+
+	return applied, nil
+}
+
+// applied versions & when they were applied.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, selectMigrations)
+	if err != nil {
+		// the table is created on first use, read-only
+		// connections can't & fail with the select error.
+		if _, cerr := m.db.ExecContext(ctx, createMigrationsTable); cerr != nil {
+			return nil, err
+		}
+
+		rows, err = m.db.QueryContext(ctx, selectMigrations)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	var applied = map[int]time.Time{}
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt int64
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = time.Unix(appliedAt, 0)
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs the up step of mg & records it in one transaction, refusing
+// to when a table it creates exists already rather than recording a
+// schema it didn't create as up to date.
+func (m *Migrator) apply(ctx context.Context, mg *Migration) error {
+	for _, table := range tablesOf(mg) {
+		if m.exists(ctx, table) {
+			return fmt.Errorf("migration %d_%s up: %w: table %s exists", mg.Version, mg.Name, ErrUntracked, table)
+		}
+	}
+
+	// no placeholders, the same statement works for every driver
+	record := fmt.Sprintf(insertMigration, mg.Version, time.Now().Unix())
+
+	if err := m.exec(ctx, mg.Up, record); err != nil {
+		return fmt.Errorf("migration %d_%s up: %w", mg.Version, mg.Name, err)
+	}
+
+	return nil
+}
+
+// baseline whether the database was created before versioning with the
+// schema of the first migration: its tables exist & none of the later ones.
+func (m *Migrator) baseline(ctx context.Context) bool {
+	if len(m.migrations) == 0 {
+		return false
+	}
+
+	first := tablesOf(m.migrations[0])
+	if len(first) == 0 {
+		return false
+	}
+
+	for _, table := range first {
+		if !m.exists(ctx, table) {
+			return false
+		}
+	}
+
+	for _, mg := range m.migrations[1:] {
+		for _, table := range tablesOf(mg) {
+			if m.exists(ctx, table) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// adopt records mg as applied without running it,
+// its schema is already in place.
+func (m *Migrator) adopt(ctx context.Context, mg *Migration) error {
+	record := fmt.Sprintf(insertMigration, mg.Version, time.Now().Unix())
+
+	if err := m.exec(ctx, record); err != nil {
+		return fmt.Errorf("migration %d_%s adopt: %w", mg.Version, mg.Name, err)
+	}
+
+	return nil
+}
+
+// tablesOf the tables the up step of mg creates.
+func tablesOf(mg *Migration) []string {
+	var tables = []string{}
+	for _, match := range createTable.FindAllStringSubmatch(mg.Up, -1) {
+		tables = append(tables, match[1])
+	}
+	return tables
+}
+
+// revert runs the down step of mg & removes its record in one transaction.
+func (m *Migrator) revert(ctx context.Context, mg *Migration) error {
+	record := fmt.Sprintf(deleteMigration, mg.Version)
+
+	if err := m.exec(ctx, mg.Down, record); err != nil {
+		return fmt.Errorf("migration %d_%s down: %w", mg.Version, mg.Name, err)
+	}
+
+	return nil
+}
+
+// exists whether table can be read, probed outside of a transaction
+// since a failed statement aborts a postgres one.
+func (m *Migrator) exists(ctx context.Context, table string) bool {
+	var one int
+	err := m.db.QueryRowContext(ctx, fmt.Sprintf(probeTable, table)).Scan(&one)
+	return err == nil || errors.Is(err, sql.ErrNoRows)
+}
+
+// exec
+func (m *Migrator) exec(ctx context.Context, queries ...string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const (
+	createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	applied_at BIGINT NOT NULL
+);`
+
+	selectMigrations = `SELECT version, applied_at FROM schema_migrations;`
+
+	insertMigration = `INSERT INTO schema_migrations (version, applied_at) VALUES (%d, %d);`
+
+	deleteMigration = `DELETE FROM schema_migrations WHERE version = %d;`
+
+	probeTable = `SELECT 1 FROM %s LIMIT 1;`
+)
@@ -0,0 +1,305 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestMigrator three migrations over a temporary sqlite database.
+func newTestMigrator(t *testing.T) (*Migrator, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := New(db, fstest.MapFS{
+		"0001_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INT);`)},
+		"0001_a.down.sql": {Data: []byte(`DROP TABLE a;`)},
+		"0002_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INT);`)},
+		"0002_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+		"0003_c.up.sql":   {Data: []byte(`CREATE TABLE c (id INT); CREATE INDEX c_idx ON c (id);`)},
+		"0003_c.down.sql": {Data: []byte(`DROP TABLE c;`)},
+		"README.md":       {Data: []byte(`ignored`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m, db
+}
+
+// tables the user tables of db.
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name != 'schema_migrations' ORDER BY name;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var names = []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// TestMigrator
+func TestMigrator(t *testing.T) {
+	m, db := newTestMigrator(t)
+
+	ctx := context.Background()
+
+	if err := m.Check(ctx); !errors.Is(err, ErrOutOfDate) {
+		t.Fatalf("got %v, want ErrOutOfDate", err)
+	}
+
+	steps := []struct {
+		name    string
+		run     func() error
+		version int
+		tables  string
+	}{
+		{"Up", func() error { return m.Up(ctx) }, 3, "[a b c]"},
+		{"Down", func() error { return m.Down(ctx, 2) }, 1, "[a]"},
+		{"GotoUp", func() error { return m.Goto(ctx, 2) }, 2, "[a b]"},
+		{"GotoZero", func() error { return m.Goto(ctx, 0) }, 0, "[]"},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		version, err := m.Version(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if version != step.version {
+			t.Errorf("%s: got version %d, want %d", step.name, version, step.version)
+		}
+
+		if got := tables(t, db); fmt.Sprint(got) != step.tables {
+			t.Errorf("%s: got tables %v, want %s", step.name, got, step.tables)
+		}
+	}
+
+	if err := m.Down(ctx, 1); err == nil {
+		t.Error("expected error reverting an empty schema")
+	}
+
+	if err := m.Goto(ctx, 4); err == nil {
+		t.Error("expected error for unknown version")
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); err != nil {
+		t.Errorf("got %v, want up to date", err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statuses) != 3 || statuses[2].Name != "c" || statuses[2].AppliedAt.IsZero() {
+		t.Errorf("unexpected status %+v", statuses)
+	}
+}
+
+// TestMigratorNewer
+func TestMigratorNewer(t *testing.T) {
+	m, db := newTestMigrator(t)
+
+	ctx := context.Background()
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// applied by a later build
+	if _, err := db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (4, 0);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); !errors.Is(err, ErrOutOfDate) {
+		t.Errorf("got %v, want ErrOutOfDate", err)
+	}
+
+	if err := m.Down(ctx, 1); !errors.Is(err, ErrOutOfDate) {
+		t.Errorf("got %v, want ErrOutOfDate", err)
+	}
+}
+
+// TestMigratorUntracked
+func TestMigratorUntracked(t *testing.T) {
+	m, db := newTestMigrator(t)
+
+	ctx := context.Background()
+
+	// created before versioning
+	if _, err := db.Exec(`CREATE TABLE b (id INT);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(ctx); !errors.Is(err, ErrUntracked) {
+		t.Fatalf("got %v, want ErrUntracked", err)
+	}
+
+	// 0001 applied, 0002 refused
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 1 {
+		t.Errorf("got version %d, want 1", version)
+	}
+
+	if err := m.Check(ctx); !errors.Is(err, ErrOutOfDate) {
+		t.Errorf("got %v, want ErrOutOfDate", err)
+	}
+}
+
+// TestMigratorBaseline
+func TestMigratorBaseline(t *testing.T) {
+	m, db := newTestMigrator(t)
+
+	ctx := context.Background()
+
+	// created before versioning with the schema of 0001
+	if _, err := db.Exec(`CREATE TABLE a (id INT); INSERT INTO a VALUES (1);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); err != nil {
+		t.Errorf("got %v, want up to date", err)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM a;`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("got %d rows, want the baseline row kept", n)
+	}
+
+	// tables of 0001 & 0003, not the baseline
+	m, db = newTestMigrator(t)
+
+	if _, err := db.Exec(`CREATE TABLE a (id INT); CREATE TABLE c (id INT);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(ctx); !errors.Is(err, ErrUntracked) {
+		t.Fatalf("got %v, want ErrUntracked", err)
+	}
+
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 0 {
+		t.Errorf("got version %d, want 0", version)
+	}
+}
+
+// TestMigratorFailure
+func TestMigratorFailure(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := New(db, fstest.MapFS{
+		"0001_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INT);`)},
+		"0001_a.down.sql": {Data: []byte(`DROP TABLE a;`)},
+		"0002_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INT); INVALID;`)},
+		"0002_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := m.Up(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// 0001 stays applied, 0002 is rolled back
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 1 {
+		t.Errorf("got version %d, want 1", version)
+	}
+
+	if got := tables(t, db); fmt.Sprint(got) != "[a]" {
+		t.Errorf("got tables %v, want [a]", got)
+	}
+}
+
+// TestNew
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "TestMissingDown",
+			fsys: fstest.MapFS{"0001_a.up.sql": {Data: []byte(`SELECT 1;`)}},
+		},
+		{
+			name: "TestNameMismatch",
+			fsys: fstest.MapFS{
+				"0001_a.up.sql":   {Data: []byte(`SELECT 1;`)},
+				"0001_b.down.sql": {Data: []byte(`SELECT 1;`)},
+			},
+		},
+		{
+			name: "TestZeroVersion",
+			fsys: fstest.MapFS{
+				"0000_a.up.sql":   {Data: []byte(`SELECT 1;`)},
+				"0000_a.down.sql": {Data: []byte(`SELECT 1;`)},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := New(nil, tc.fsys); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
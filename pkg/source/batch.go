@@ -0,0 +1,170 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Batcher sources fetching many blocks or receipts
+// per round trip, with JSON-RPC batch calls.
+type Batcher interface {
+	// BlocksByNumber returns the blocks in the order of numbers,
+	// fails with ethereum.NotFound if any of them is unknown.
+	BlocksByNumber(ctx context.Context, numbers []*big.Int) ([]*types.Block, error)
+	// TransactionReceipts returns the receipts in the order of hashes,
+	// nil for the unknown ones.
+	TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, error)
+}
+
+var (
+	_ Batcher = (*HTTP)(nil)
+	_ Batcher = (*RPC)(nil)
+)
+
+// batchLimit max calls per request, nodes cap the size
+// of batches (e.g. geth at 1000 calls) & so do providers.
+const batchLimit = 100
+
+// batchFunc executes calls in one request, e.g. rpc.Client.BatchCallContext
+type batchFunc func(ctx context.Context, elems []rpc.BatchElem) error
+
+// batch executes elems in requests of at most batchLimit calls,
+// returns the first error of a request or of a call.
+func batch(ctx context.Context, call batchFunc, elems []rpc.BatchElem) error {
+	for i := 0; i < len(elems); i += batchLimit {
+		if err := call(ctx, elems[i:min(i+batchLimit, len(elems))]); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range elems {
+		if e.Error != nil {
+			return fmt.Errorf("%s: %w", e.Method, e.Error)
+		}
+	}
+
+	return nil
+}
+
+// blocksByNumber fetches the blocks, then their uncles, in batches.
+func blocksByNumber(ctx context.Context, call batchFunc, numbers []*big.Int) ([]*types.Block, error) {
+	var (
+		raws  = make([]json.RawMessage, len(numbers))
+		elems = make([]rpc.BatchElem, 0, len(numbers))
+	)
+
+	for i, n := range numbers {
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{toBlockNumArg(n), true},
+			Result: &raws[i],
+		})
+	}
+
+	if err := batch(ctx, call, elems); err != nil {
+		return nil, err
+	}
+
+	var (
+		heads  = make([]*types.Header, len(numbers))
+		bodies = make([]*rpcBlock, len(numbers))
+		uncles = make([][]*types.Header, len(numbers))
+	)
+
+	elems = elems[:0]
+
+	for i, raw := range raws {
+		if len(raw) == 0 || string(raw) == "null" {
+			return nil, fmt.Errorf("block %s: %w", numbers[i], ethereum.NotFound)
+		}
+
+		head, body, err := decodeBlock(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		heads[i], bodies[i] = head, body
+
+		// uncles are not included in the block
+		uncles[i] = make([]*types.Header, len(body.UncleHashes))
+		for j := range body.UncleHashes {
+			elems = append(elems, rpc.BatchElem{
+				Method: "eth_getUncleByBlockHashAndIndex",
+				Args:   []any{body.Hash, hexutil.Uint(j)},
+				Result: &uncles[i][j],
+			})
+		}
+	}
+
+	if err := batch(ctx, call, elems); err != nil {
+		return nil, err
+	}
+
+	var blocks = make([]*types.Block, 0, len(numbers))
+
+	for i, body := range bodies {
+		for j, u := range uncles[i] {
+			if u == nil {
+				return nil, fmt.Errorf("uncle %d of block %s not found", j, body.Hash.Hex())
+			}
+		}
+
+		blocks = append(blocks, types.NewBlockWithHeader(heads[i]).WithBody(types.Body{
+			Transactions: body.Transactions,
+			Uncles:       uncles[i],
+			Withdrawals:  body.Withdrawals,
+		}))
+	}
+
+	return blocks, nil
+}
+
+// transactionReceipts fetches the receipts in batches.
+func transactionReceipts(ctx context.Context, call batchFunc, hashes []common.Hash) ([]*types.Receipt, error) {
+	var (
+		receipts = make([]*types.Receipt, len(hashes))
+		elems    = make([]rpc.BatchElem, 0, len(hashes))
+	)
+
+	for i, h := range hashes {
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []any{h},
+			Result: &receipts[i],
+		})
+	}
+
+	if err := batch(ctx, call, elems); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// BlocksByNumber executes `eth_getBlockByNumber` in batches.
+func (h *HTTP) BlocksByNumber(ctx context.Context, numbers []*big.Int) ([]*types.Block, error) {
+	return blocksByNumber(ctx, h.batchCall, numbers)
+}
+
+// TransactionReceipts executes `eth_getTransactionReceipt` in batches.
+func (h *HTTP) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, error) {
+	return transactionReceipts(ctx, h.batchCall, hashes)
+}
+
+// BlocksByNumber executes `eth_getBlockByNumber` in batches.
+func (r *RPC) BlocksByNumber(ctx context.Context, numbers []*big.Int) ([]*types.Block, error) {
+	return blocksByNumber(ctx, r.Client.Client().BatchCallContext, numbers)
+}
+
+// TransactionReceipts executes `eth_getTransactionReceipt` in batches.
+func (r *RPC) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, error) {
+	return transactionReceipts(ctx, r.Client.Client().BatchCallContext, hashes)
+}
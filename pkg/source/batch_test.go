@@ -0,0 +1,174 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testChain blocks with one tx each, served over JSON-RPC batches.
+type testChain struct {
+	blocks   map[uint64]map[string]any // eth_getBlockByNumber results
+	receipts map[common.Hash]*types.Receipt
+	requests atomic.Int64
+}
+
+// newTestChain blocks 1 to n.
+func newTestChain(t *testing.T, n uint64) *testChain {
+	t.Helper()
+
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	c := &testChain{
+		blocks:   map[uint64]map[string]any{},
+		receipts: map[common.Hash]*types.Receipt{},
+	}
+
+	for i := uint64(1); i <= n; i++ {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    i,
+			GasPrice: big.NewInt(1),
+			Gas:      21000,
+			Value:    big.NewInt(int64(i)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(i),
+			Difficulty: new(big.Int),
+			GasLimit:   30_000_000,
+			Time:       i,
+		}
+
+		// header fields & the body, as returned by a node
+		raw, _ := json.Marshal(header)
+		var block map[string]any
+		json.Unmarshal(raw, &block)
+
+		block["hash"] = header.Hash()
+		block["transactions"] = []*types.Transaction{tx}
+		block["uncles"] = []common.Hash{}
+
+		c.blocks[i] = block
+		c.receipts[tx.Hash()] = &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000,
+			GasUsed:           21000,
+			TxHash:            tx.Hash(),
+			Logs:              []*types.Log{},
+		}
+	}
+
+	return c
+}
+
+// ServeHTTP answers batches in reverse order, ids must be matched.
+func (c *testChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.requests.Add(1)
+
+	var reqs []struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resps = []map[string]any{}
+
+	for i := len(reqs) - 1; i >= 0; i-- {
+		var result any
+
+		switch reqs[i].Method {
+		case "eth_getBlockByNumber":
+			var n hexutil.Uint64
+			json.Unmarshal(reqs[i].Params[0], &n)
+			if b, found := c.blocks[uint64(n)]; found {
+				result = b
+			}
+		case "eth_getTransactionReceipt":
+			var h common.Hash
+			json.Unmarshal(reqs[i].Params[0], &h)
+			if r, found := c.receipts[h]; found {
+				result = r
+			}
+		}
+
+		resps = append(resps, map[string]any{"jsonrpc": "2.0", "id": reqs[i].ID, "result": result})
+	}
+
+	json.NewEncoder(w).Encode(resps)
+}
+
+// TestBatch
+func TestBatch(t *testing.T) {
+	c := newTestChain(t, 150)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	h := NewHTTP(srv.URL)
+
+	ctx := context.Background()
+
+	var numbers = []*big.Int{}
+	for i := int64(1); i <= 150; i++ {
+		numbers = append(numbers, big.NewInt(i))
+	}
+
+	blocks, err := h.BlocksByNumber(ctx, numbers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashes = []common.Hash{}
+	for i, b := range blocks {
+		if b.NumberU64() != uint64(i+1) || len(b.Transactions()) != 1 {
+			t.Fatalf("got block %d with %d txs at %d", b.NumberU64(), len(b.Transactions()), i)
+		}
+		hashes = append(hashes, b.Transactions()[0].Hash())
+	}
+
+	// unknown receipt
+	hashes = append(hashes, common.HexToHash("0x01"))
+
+	receipts, err := h.TransactionReceipts(ctx, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range receipts[:150] {
+		if r == nil || r.TxHash != hashes[i] {
+			t.Fatalf("got receipt %+v for tx %d", r, i)
+		}
+	}
+
+	if receipts[150] != nil {
+		t.Errorf("got receipt %+v, want nil", receipts[150])
+	}
+
+	// 2 requests of at most 100 calls for each
+	if n := c.requests.Load(); n != 4 {
+		t.Errorf("got %d requests, want 4", n)
+	}
+
+	if _, err := h.BlocksByNumber(ctx, []*big.Int{big.NewInt(1), big.NewInt(151)}); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("got %v, want ethereum.NotFound", err)
+	}
+}
@@ -0,0 +1,137 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockFetcher sources choosing which node serves a fetch, e.g. a pool,
+// blocks & their receipts must come from the same one.
+type BlockFetcher interface {
+	// FetchBlocks returns the blocks in the order of numbers along with
+	// the receipts of their txs, as FetchBlocks does.
+	FetchBlocks(ctx context.Context, numbers []*big.Int) ([]*types.Block, [][]*types.Receipt, error)
+}
+
+var _ BlockFetcher = (*Pool)(nil)
+
+// FetchBlocks the blocks of numbers & the receipts of their txs, nil when
+// s doesn't support receipts (e.g. a replay file). A missing receipt, or
+// one of another block (e.g. a reorg in between), fails the whole fetch.
+// Batchers fetch all blocks in one round trip, then all receipts in another.
+func FetchBlocks(ctx context.Context, s ChainSource, numbers []*big.Int) ([]*types.Block, [][]*types.Receipt, error) {
+	if f, ok := s.(BlockFetcher); ok {
+		return f.FetchBlocks(ctx, numbers)
+	}
+
+	return fetchBlocks(ctx, s, numbers)
+}
+
+// fetchBlocks
+func fetchBlocks(ctx context.Context, s ChainSource, numbers []*big.Int) ([]*types.Block, [][]*types.Receipt, error) {
+	b, ok := s.(Batcher)
+	if !ok {
+		return fetchOneByOne(ctx, s, numbers)
+	}
+
+	blocks, err := b.BlocksByNumber(ctx, numbers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var hashes = []common.Hash{}
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			hashes = append(hashes, tx.Hash())
+		}
+	}
+
+	all, err := b.TransactionReceipts(ctx, hashes)
+	switch {
+	case errors.Is(err, ErrReceiptUnsupported):
+		all = make([]*types.Receipt, len(hashes))
+	case err != nil:
+		return nil, nil, err
+	}
+
+	// split per block
+	var receipts = make([][]*types.Receipt, 0, len(blocks))
+	for _, block := range blocks {
+		n := len(block.Transactions())
+		receipts = append(receipts, all[:n])
+		all = all[n:]
+	}
+
+	if err == nil {
+		for i, block := range blocks {
+			if err := checkReceipts(block, receipts[i]); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return blocks, receipts, nil
+}
+
+// fetchOneByOne
+func fetchOneByOne(ctx context.Context, s ChainSource, numbers []*big.Int) ([]*types.Block, [][]*types.Receipt, error) {
+	var (
+		blocks   = make([]*types.Block, 0, len(numbers))
+		receipts = make([][]*types.Receipt, 0, len(numbers))
+	)
+
+	for _, n := range numbers {
+		block, err := s.BlockByNumber(ctx, n)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var (
+			rs          = make([]*types.Receipt, 0, len(block.Transactions()))
+			unsupported bool
+		)
+
+		for _, tx := range block.Transactions() {
+			r, err := s.TransactionReceipt(ctx, tx.Hash())
+			if errors.Is(err, ErrReceiptUnsupported) {
+				unsupported = true
+			} else if err != nil {
+				return nil, nil, fmt.Errorf("receipt of tx %s: %w", tx.Hash().Hex(), err)
+			}
+			rs = append(rs, r)
+		}
+
+		if !unsupported {
+			if err := checkReceipts(block, rs); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		blocks = append(blocks, block)
+		receipts = append(receipts, rs)
+	}
+
+	return blocks, receipts, nil
+}
+
+// checkReceipts every tx of block has a receipt of block.
+func checkReceipts(block *types.Block, receipts []*types.Receipt) error {
+	for i, tx := range block.Transactions() {
+		r := receipts[i]
+		if r == nil {
+			return fmt.Errorf("receipt of tx %s: %w", tx.Hash().Hex(), ethereum.NotFound)
+		}
+
+		if r.BlockHash != block.Hash() {
+			return fmt.Errorf("receipt of tx %s is of block %s, want %s", tx.Hash().Hex(), r.BlockHash.Hex(), block.Hash().Hex())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,348 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// HTTP plain JSON-RPC client over HTTP
+type HTTP struct {
+	endpoint string
+	client   *http.Client
+	id       *atomic.Uint64
+}
+
+// NewHTTP
+func NewHTTP(endpoint string) *HTTP {
+	return &HTTP{
+		endpoint: endpoint,
+		client:   &http.Client{},
+		id:       &atomic.Uint64{},
+	}
+}
+
+// request
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+// response
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// rpcError
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// ErrorCode implements rpc.Error
+func (e *rpcError) ErrorCode() int {
+	return e.Code
+}
+
+// call executes method and decodes its result into result,
+// a null result leaves result untouched.
+func (h *HTTP) call(ctx context.Context, result any, method string, params ...any) error {
+	if params == nil {
+		params = []any{}
+	}
+
+	var r response
+	if err := h.post(ctx, request{
+		JSONRPC: "2.0",
+		ID:      h.id.Add(1),
+		Method:  method,
+		Params:  params,
+	}, &r); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	if r.Error != nil {
+		return r.Error
+	}
+
+	if len(r.Result) == 0 || string(r.Result) == "null" {
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, result)
+}
+
+// batchCall executes elems in a single request, as rpc.Client does:
+// call errors are set on each element, a null result leaves it untouched.
+func (h *HTTP) batchCall(ctx context.Context, elems []rpc.BatchElem) error {
+	var (
+		reqs = make([]request, 0, len(elems))
+		byID = make(map[uint64]int, len(elems))
+	)
+
+	for i, e := range elems {
+		params := e.Args
+		if params == nil {
+			params = []any{}
+		}
+
+		id := h.id.Add(1)
+		byID[id] = i
+
+		reqs = append(reqs, request{
+			JSONRPC: "2.0",
+			ID:      id,
+			Method:  e.Method,
+			Params:  params,
+		})
+	}
+
+	var resps []response
+	if err := h.post(ctx, reqs, &resps); err != nil {
+		return fmt.Errorf("batch: %w", err)
+	}
+
+	// responses may come in any order
+	for _, r := range resps {
+		i, found := byID[r.ID]
+		if !found {
+			continue
+		}
+		delete(byID, r.ID)
+
+		switch {
+		case r.Error != nil:
+			elems[i].Error = r.Error
+		case len(r.Result) == 0 || string(r.Result) == "null":
+		default:
+			elems[i].Error = json.Unmarshal(r.Result, elems[i].Result)
+		}
+	}
+
+	for _, i := range byID {
+		elems[i].Error = fmt.Errorf("%s: missing batch response", elems[i].Method)
+	}
+
+	return nil
+}
+
+// post sends payload & decodes the response body into out.
+func (h *HTTP) post(ctx context.Context, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ChainID
+func (h *HTTP) ChainID(ctx context.Context) (*big.Int, error) {
+	var id hexutil.Big
+	if err := h.call(ctx, &id, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&id), nil
+}
+
+// BlockNumber
+func (h *HTTP) BlockNumber(ctx context.Context) (uint64, error) {
+	var n hexutil.Uint64
+	if err := h.call(ctx, &n, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+// rpcBlock block body as returned by `eth_getBlockByNumber`
+type rpcBlock struct {
+	Hash         common.Hash          `json:"hash"`
+	Transactions []*types.Transaction `json:"transactions"`
+	UncleHashes  []common.Hash        `json:"uncles"`
+	Withdrawals  []*types.Withdrawal  `json:"withdrawals"`
+}
+
+// decodeBlock splits an `eth_getBlockByNumber` result with
+// full transactions into the header & the block body.
+func decodeBlock(raw json.RawMessage) (*types.Header, *rpcBlock, error) {
+	var head *types.Header
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, nil, err
+	}
+
+	var body rpcBlock
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, nil, err
+	}
+
+	return head, &body, nil
+}
+
+// BlockByNumber - nil number returns the latest block.
+func (h *HTTP) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	var raw json.RawMessage
+	if err := h.call(ctx, &raw, "eth_getBlockByNumber", toBlockNumArg(number), true); err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, ethereum.NotFound
+	}
+
+	head, body, err := decodeBlock(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// uncles are not included in the block
+	uncles := make([]*types.Header, len(body.UncleHashes))
+	for i := range body.UncleHashes {
+		if err := h.call(ctx, &uncles[i], "eth_getUncleByBlockHashAndIndex", body.Hash, hexutil.Uint(i)); err != nil {
+			return nil, err
+		}
+
+		if uncles[i] == nil {
+			return nil, fmt.Errorf("uncle %d of block %s not found", i, body.Hash.Hex())
+		}
+	}
+
+	return types.NewBlockWithHeader(head).WithBody(types.Body{
+		Transactions: body.Transactions,
+		Uncles:       uncles,
+		Withdrawals:  body.Withdrawals,
+	}), nil
+}
+
+// HeaderByNumber - nil number returns the latest header.
+func (h *HTTP) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var head *types.Header
+	if err := h.call(ctx, &head, "eth_getBlockByNumber", toBlockNumArg(number), false); err != nil {
+		return nil, err
+	}
+
+	if head == nil {
+		return nil, ethereum.NotFound
+	}
+
+	return head, nil
+}
+
+// TransactionCount
+func (h *HTTP) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	var count *hexutil.Uint
+	if err := h.call(ctx, &count, "eth_getBlockTransactionCountByHash", blockHash); err != nil {
+		return 0, err
+	}
+
+	if count == nil {
+		return 0, ethereum.NotFound
+	}
+
+	return uint(*count), nil
+}
+
+// TransactionReceipt
+func (h *HTTP) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	if err := h.call(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, err
+	}
+
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+
+	return receipt, nil
+}
+
+// CallContract executes `eth_call`, nil block number runs the call at the latest block.
+func (h *HTTP) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	arg := map[string]any{
+		"from": call.From,
+		"to":   call.To,
+	}
+	if len(call.Data) > 0 {
+		arg["data"] = hexutil.Bytes(call.Data)
+	}
+	if call.Value != nil {
+		arg["value"] = (*hexutil.Big)(call.Value)
+	}
+	if call.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(call.Gas)
+	}
+
+	var out hexutil.Bytes
+	if err := h.call(ctx, &out, "eth_call", arg, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// BalanceAt executes `eth_getBalance`, nil block number returns the latest balance.
+func (h *HTTP) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance hexutil.Big
+	if err := h.call(ctx, &balance, "eth_getBalance", account, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+
+	return (*big.Int)(&balance), nil
+}
+
+// CodeAt executes `eth_getCode`, nil block number returns the latest code.
+func (h *HTTP) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code hexutil.Bytes
+	if err := h.call(ctx, &code, "eth_getCode", account, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// SubscribeNewHead - not supported over HTTP.
+func (h *HTTP) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, ErrSubscriptionUnsupported
+}
+
+// toBlockNumArg
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
@@ -0,0 +1,552 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/twiny/ratelimit"
+)
+
+// ErrNoEndpoint returned by a pool without an endpoint able to serve the call.
+var ErrNoEndpoint = errors.New("source: no endpoint available")
+
+// limitExceeded JSON-RPC code of providers rate limiting requests
+const limitExceeded = -32005
+
+// Endpoint a source of a pool.
+type Endpoint struct {
+	Name    string // shown in the pool status, e.g. the url host
+	Source  ChainSource
+	Weight  int                // share of the calls, at least 1
+	Limiter *ratelimit.Limiter // taken by every request, nil for no limit
+}
+
+// EndpointStatus health of a pool endpoint
+type EndpointStatus struct {
+	Name    string `json:"name"`
+	Weight  int    `json:"weight"`
+	Active  bool   `json:"active"` // preferred endpoint, e.g. for head subscriptions
+	Healthy bool   `json:"healthy"`
+	Head    int64  `json:"head"` // as of the last health check
+	Error   string `json:"error,omitempty"`
+}
+
+// member an endpoint & its health
+type member struct {
+	*Endpoint
+	mu      *sync.Mutex
+	healthy bool
+	head    int64
+	err     error
+}
+
+// Pool spreads calls over endpoints by weight. A call failing on the
+// endpoint side (e.g. a dropped connection or a rate limit) is retried on
+// the next endpoint, & the failed one is skipped until a health check
+// finds it back within maxLag blocks of the highest head. A block not found
+// on an endpoint whose head is below it is looked up on the next one too.
+type Pool struct {
+	members []*member
+	maxLag  int64
+}
+
+var (
+	_ ChainSource = (*Pool)(nil)
+	_ Batcher     = (*Pool)(nil)
+	_ Tracer      = (*Pool)(nil)
+)
+
+// latest height of calls not about a given block
+const latest = -1
+
+// NewPool endpoints are healthy until checked.
+func NewPool(endpoints []*Endpoint, maxLag int64) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoint
+	}
+
+	var members = make([]*member, 0, len(endpoints))
+
+	for _, e := range endpoints {
+		e.Weight = max(e.Weight, 1)
+
+		members = append(members, &member{
+			Endpoint: e,
+			mu:       &sync.Mutex{},
+			healthy:  true,
+		})
+	}
+
+	return &Pool{
+		members: members,
+		maxLag:  maxLag,
+	}, nil
+}
+
+// Watch checks the endpoints every interval until ctx is done,
+// a check not answered within the interval fails.
+func (p *Pool) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		p.check(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check fetches the latest header of every endpoint at once, those
+// failing or more than maxLag blocks behind the highest head are unhealthy.
+func (p *Pool) check(ctx context.Context, timeout time.Duration) {
+	var (
+		wg    = &sync.WaitGroup{}
+		heads = make([]int64, len(p.members))
+		errs  = make([]error, len(p.members))
+	)
+
+	for i, m := range p.members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			header, err := m.Source.HeaderByNumber(ctx, nil)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			heads[i] = header.Number.Int64()
+		}()
+	}
+
+	wg.Wait()
+
+	// stopped, not the endpoints' fault
+	if ctx.Err() != nil {
+		return
+	}
+
+	var best int64
+	for _, h := range heads {
+		best = max(best, h)
+	}
+
+	for i, m := range p.members {
+		err := errs[i]
+		if err == nil && best-heads[i] > p.maxLag {
+			err = fmt.Errorf("%d blocks behind", best-heads[i])
+		}
+
+		m.mu.Lock()
+		if errs[i] == nil {
+			m.head = heads[i]
+		}
+		m.healthy = err == nil
+		m.err = err
+		m.mu.Unlock()
+	}
+}
+
+// Status of every endpoint, in configuration order.
+func (p *Pool) Status() []*EndpointStatus {
+	var (
+		active   = p.preferred()
+		statuses = make([]*EndpointStatus, 0, len(p.members))
+	)
+
+	for i, m := range p.members {
+		m.mu.Lock()
+		s := &EndpointStatus{
+			Name:    m.Name,
+			Weight:  m.Weight,
+			Active:  len(active) > 0 && active[0] == i,
+			Healthy: m.healthy,
+			Head:    m.head,
+		}
+		if m.err != nil {
+			s.Error = m.err.Error()
+		}
+		m.mu.Unlock()
+
+		statuses = append(statuses, s)
+	}
+
+	return statuses
+}
+
+// CanTrace whether any endpoint can trace calls.
+func (p *Pool) CanTrace() bool {
+	for _, m := range p.members {
+		if canTrace(m.Source) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail marks m unhealthy until the next check.
+func (m *member) fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.healthy = false
+	m.err = err
+}
+
+// isHealthy
+func (m *member) isHealthy() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.healthy
+}
+
+// behind whether m's head, as of the last check, is below height.
+func (m *member) behind(height int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return height != latest && m.head < height
+}
+
+// preferred member indexes, healthy ones first then by weight,
+// configuration order breaks ties.
+func (p *Pool) preferred() []int {
+	var (
+		order   = make([]int, 0, len(p.members))
+		healthy = make([]bool, len(p.members))
+	)
+
+	for i, m := range p.members {
+		order = append(order, i)
+		healthy[i] = m.isHealthy()
+	}
+
+	sort.SliceStable(order, func(x, y int) bool {
+		a, b := order[x], order[y]
+		if healthy[a] != healthy[b] {
+			return healthy[a]
+		}
+		return p.members[a].Weight > p.members[b].Weight
+	})
+
+	return order
+}
+
+// pick a member able to serve the call & not tried yet, at random by
+// weight among healthy ones, else the heaviest; -1 when none is left.
+func (p *Pool) pick(tried []bool, can func(ChainSource) bool) int {
+	var (
+		total     int
+		fallback  = -1
+		candidate = make([]bool, len(p.members))
+	)
+
+	for i, m := range p.members {
+		if tried[i] || !can(m.Source) {
+			continue
+		}
+
+		if m.isHealthy() {
+			candidate[i] = true
+			total += m.Weight
+			continue
+		}
+
+		if fallback < 0 || m.Weight > p.members[fallback].Weight {
+			fallback = i
+		}
+	}
+
+	if total == 0 {
+		return fallback
+	}
+
+	n := rand.IntN(total)
+	for i, m := range p.members {
+		if !candidate[i] {
+			continue
+		}
+		if n < m.Weight {
+			return i
+		}
+		n -= m.Weight
+	}
+
+	return fallback
+}
+
+// do runs fn on picked members until it succeeds or fails with an answer,
+// e.g. a reverted call or a block unknown to a member at height or above.
+func (p *Pool) do(ctx context.Context, can func(ChainSource) bool, height int64, fn func(s ChainSource) error) error {
+	var (
+		tried = make([]bool, len(p.members))
+		err   = ErrNoEndpoint
+	)
+
+	for {
+		i := p.pick(tried, can)
+		if i < 0 {
+			return err
+		}
+		tried[i] = true
+
+		m := p.members[i]
+		if m.Limiter != nil {
+			m.Limiter.Take()
+		}
+
+		err = fn(m.Source)
+		if err == nil {
+			return nil
+		}
+
+		// not there yet, the member stays healthy
+		if errors.Is(err, ethereum.NotFound) && ctx.Err() == nil && m.behind(height) {
+			err = fmt.Errorf("%s: %w", m.Name, err)
+			continue
+		}
+
+		if !failover(ctx, err) {
+			return err
+		}
+
+		m.fail(err)
+		err = fmt.Errorf("%s: %w", m.Name, err)
+	}
+}
+
+// failover whether err is the endpoint's fault, worth a retry on another one.
+func failover(ctx context.Context, err error) bool {
+	// the caller gave up
+	if ctx.Err() != nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, ethereum.NotFound),
+		errors.Is(err, ErrSubscriptionUnsupported),
+		errors.Is(err, ErrCallUnsupported),
+		errors.Is(err, ErrStateUnsupported),
+		errors.Is(err, ErrReceiptUnsupported):
+		return false
+	}
+
+	// JSON-RPC errors are answers, unless the endpoint is rate limiting
+	var rerr rpc.Error
+	if errors.As(err, &rerr) {
+		return rerr.ErrorCode() == limitExceeded
+	}
+
+	return true
+}
+
+// call runs fn through p.do & returns its value.
+func call[T any](ctx context.Context, p *Pool, can func(ChainSource) bool, height int64, fn func(s ChainSource) (T, error)) (T, error) {
+	var v T
+
+	err := p.do(ctx, can, height, func(s ChainSource) error {
+		var err error
+		v, err = fn(s)
+		return err
+	})
+
+	return v, err
+}
+
+// heightOf number, latest when nil (or a tag, e.g. pending)
+func heightOf(number *big.Int) int64 {
+	if number == nil || number.Sign() < 0 {
+		return latest
+	}
+	return number.Int64()
+}
+
+// maxHeight of numbers
+func maxHeight(numbers []*big.Int) int64 {
+	var height int64 = latest
+	for _, n := range numbers {
+		height = max(height, heightOf(n))
+	}
+	return height
+}
+
+// anySource every source serves ChainSource calls
+func anySource(ChainSource) bool {
+	return true
+}
+
+// canTrace
+func canTrace(s ChainSource) bool {
+	_, ok := s.(Tracer)
+	return ok
+}
+
+// ChainID
+func (p *Pool) ChainID(ctx context.Context) (*big.Int, error) {
+	return call(ctx, p, anySource, latest, func(s ChainSource) (*big.Int, error) {
+		return s.ChainID(ctx)
+	})
+}
+
+// BlockNumber
+func (p *Pool) BlockNumber(ctx context.Context) (uint64, error) {
+	return call(ctx, p, anySource, latest, func(s ChainSource) (uint64, error) {
+		return s.BlockNumber(ctx)
+	})
+}
+
+// BlockByNumber
+func (p *Pool) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return call(ctx, p, anySource, heightOf(number), func(s ChainSource) (*types.Block, error) {
+		return s.BlockByNumber(ctx, number)
+	})
+}
+
+// HeaderByNumber
+func (p *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return call(ctx, p, anySource, heightOf(number), func(s ChainSource) (*types.Header, error) {
+		return s.HeaderByNumber(ctx, number)
+	})
+}
+
+// TransactionCount
+func (p *Pool) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return call(ctx, p, anySource, latest, func(s ChainSource) (uint, error) {
+		return s.TransactionCount(ctx, blockHash)
+	})
+}
+
+// TransactionReceipt
+func (p *Pool) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return call(ctx, p, anySource, latest, func(s ChainSource) (*types.Receipt, error) {
+		return s.TransactionReceipt(ctx, txHash)
+	})
+}
+
+// CallContract
+func (p *Pool) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, p, anySource, heightOf(blockNumber), func(s ChainSource) ([]byte, error) {
+		return s.CallContract(ctx, msg, blockNumber)
+	})
+}
+
+// BalanceAt
+func (p *Pool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return call(ctx, p, anySource, heightOf(blockNumber), func(s ChainSource) (*big.Int, error) {
+		return s.BalanceAt(ctx, account, blockNumber)
+	})
+}
+
+// CodeAt
+func (p *Pool) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return call(ctx, p, anySource, heightOf(blockNumber), func(s ChainSource) ([]byte, error) {
+		return s.CodeAt(ctx, account, blockNumber)
+	})
+}
+
+// SubscribeNewHead subscribes on the preferred endpoint able to push heads.
+func (p *Pool) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	var err error = ErrSubscriptionUnsupported
+
+	for _, i := range p.preferred() {
+		m := p.members[i]
+
+		sub, serr := m.Source.SubscribeNewHead(ctx, ch)
+		if serr == nil {
+			return sub, nil
+		}
+
+		if errors.Is(serr, ErrSubscriptionUnsupported) {
+			continue
+		}
+
+		m.fail(serr)
+		err = fmt.Errorf("%s: %w", m.Name, serr)
+	}
+
+	return nil, err
+}
+
+// BlocksByNumber in a batch when the picked endpoint supports it,
+// otherwise one by one.
+func (p *Pool) BlocksByNumber(ctx context.Context, numbers []*big.Int) ([]*types.Block, error) {
+	return call(ctx, p, anySource, maxHeight(numbers), func(s ChainSource) ([]*types.Block, error) {
+		if b, ok := s.(Batcher); ok {
+			return b.BlocksByNumber(ctx, numbers)
+		}
+
+		var blocks = make([]*types.Block, 0, len(numbers))
+		for _, n := range numbers {
+			block, err := s.BlockByNumber(ctx, n)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		}
+
+		return blocks, nil
+	})
+}
+
+// TransactionReceipts in a batch when the picked endpoint supports it,
+// otherwise one by one.
+func (p *Pool) TransactionReceipts(ctx context.Context, hashes []common.Hash) ([]*types.Receipt, error) {
+	return call(ctx, p, anySource, latest, func(s ChainSource) ([]*types.Receipt, error) {
+		if b, ok := s.(Batcher); ok {
+			return b.TransactionReceipts(ctx, hashes)
+		}
+
+		var receipts = make([]*types.Receipt, 0, len(hashes))
+		for _, h := range hashes {
+			r, err := s.TransactionReceipt(ctx, h)
+			if err != nil && !errors.Is(err, ethereum.NotFound) {
+				return nil, err
+			}
+			receipts = append(receipts, r)
+		}
+
+		return receipts, nil
+	})
+}
+
+// FetchBlocks blocks & their receipts from the same endpoint,
+// the next one is tried when any of them fails.
+func (p *Pool) FetchBlocks(ctx context.Context, numbers []*big.Int) ([]*types.Block, [][]*types.Receipt, error) {
+	var (
+		blocks   []*types.Block
+		receipts [][]*types.Receipt
+	)
+
+	err := p.do(ctx, anySource, maxHeight(numbers), func(s ChainSource) error {
+		var err error
+		blocks, receipts, err = fetchBlocks(ctx, s, numbers)
+		return err
+	})
+
+	return blocks, receipts, err
+}
+
+// TraceBlockCalls on endpoints able to trace.
+func (p *Pool) TraceBlockCalls(ctx context.Context, hash common.Hash) ([]*CallFrame, error) {
+	return call(ctx, p, canTrace, latest, func(s ChainSource) ([]*CallFrame, error) {
+		return s.(Tracer).TraceBlockCalls(ctx, hash)
+	})
+}
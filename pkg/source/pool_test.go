@@ -0,0 +1,239 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeSource answers headers at head & blocks up to head, or fails with err.
+type fakeSource struct {
+	ChainSource
+	name   string
+	head   int64
+	err    error
+	stale  bool                        // receipts of another block
+	blocks map[common.Hash]common.Hash // block hash by tx hash
+	calls  atomic.Int64
+}
+
+// HeaderByNumber
+func (f *fakeSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	f.calls.Add(1)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &types.Header{Number: big.NewInt(f.head)}, nil
+}
+
+// BlockByNumber a block of one tx, marked with f's name.
+func (f *fakeSource) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	f.calls.Add(1)
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if number.Int64() > f.head {
+		return nil, ethereum.NotFound
+	}
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: number.Uint64()})
+	block := types.NewBlockWithHeader(&types.Header{Number: number, Extra: []byte(f.name)}).WithBody(types.Body{
+		Transactions: []*types.Transaction{tx},
+	})
+
+	if f.blocks == nil {
+		f.blocks = map[common.Hash]common.Hash{}
+	}
+	f.blocks[tx.Hash()] = block.Hash()
+
+	return block, nil
+}
+
+// TransactionReceipt of a tx of a block f returned.
+func (f *fakeSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	f.calls.Add(1)
+
+	hash, found := f.blocks[txHash]
+	if !found {
+		return nil, ethereum.NotFound
+	}
+
+	if f.stale {
+		hash = common.Hash{}
+	}
+
+	return &types.Receipt{TxHash: txHash, BlockHash: hash}, nil
+}
+
+// TestPool
+func TestPool(t *testing.T) {
+	var (
+		a = &fakeSource{head: 100}
+		b = &fakeSource{head: 100}
+	)
+
+	p, err := NewPool([]*Endpoint{
+		{Name: "a", Source: a, Weight: 3},
+		{Name: "b", Source: b},
+	}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// spread by weight
+	for i := 0; i < 400; i++ {
+		if _, err := p.HeaderByNumber(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := a.calls.Load(); n < 240 || n > 360 {
+		t.Errorf("got %d of 400 calls on a, want about 300", n)
+	}
+
+	// answers are not failed over
+	a.err, b.err = &rpcError{Code: 3, Message: "execution reverted"}, nil
+	a.calls.Store(0)
+	b.calls.Store(0)
+
+	for i := 0; i < 20; i++ {
+		p.HeaderByNumber(ctx, nil)
+	}
+
+	if n := b.calls.Load(); n == 20 {
+		t.Errorf("got all calls on b, want rpc errors not to fail over")
+	}
+
+	// transport errors are
+	a.err = errors.New("connection refused")
+	a.calls.Store(0)
+
+	for i := 0; i < 20; i++ {
+		if _, err := p.HeaderByNumber(ctx, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := a.calls.Load(); n != 1 {
+		t.Errorf("got %d calls on a, want 1 until it is checked again", n)
+	}
+
+	if s := p.Status(); s[0].Healthy || !s[1].Active {
+		t.Errorf("got a healthy %v & b active %v, want b active", s[0].Healthy, s[1].Active)
+	}
+
+	// back, but lagging
+	a.err, a.head, b.head = nil, 90, 100
+	p.check(ctx, time.Second)
+
+	s := p.Status()
+	if s[0].Healthy || s[0].Head != 90 || !s[1].Healthy || s[1].Head != 100 {
+		t.Errorf("got %+v & %+v, want a lagging", s[0], s[1])
+	}
+
+	// caught up
+	a.head = 98
+	p.check(ctx, time.Second)
+
+	if s := p.Status(); !s[0].Healthy || !s[0].Active {
+		t.Errorf("got %+v, want a healthy & active", s[0])
+	}
+
+	// every endpoint down
+	a.err, b.err = errors.New("a down"), errors.New("b down")
+
+	if _, err := p.HeaderByNumber(ctx, nil); err == nil {
+		t.Errorf("got no error, want the last endpoint error")
+	}
+
+	if _, err := p.TraceBlockCalls(ctx, common.Hash{}); !errors.Is(err, ErrNoEndpoint) {
+		t.Errorf("got %v, want ErrNoEndpoint", err)
+	}
+}
+
+// TestPoolFetchBlocks
+func TestPoolFetchBlocks(t *testing.T) {
+	var (
+		a = &fakeSource{name: "a", head: 10, stale: true}
+		b = &fakeSource{name: "b", head: 10}
+	)
+
+	p, err := NewPool([]*Endpoint{
+		{Name: "a", Source: a, Weight: 100},
+		{Name: "b", Source: b},
+	}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// receipts of another block fail over
+	for i := 0; i < 5; i++ {
+		blocks, receipts, err := FetchBlocks(ctx, p, []*big.Int{big.NewInt(8)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(blocks[0].Extra()) != "b" || receipts[0][0].BlockHash != blocks[0].Hash() {
+			t.Fatalf("got block of %s & receipt of %s, want both of b", blocks[0].Extra(), receipts[0][0].BlockHash.Hex())
+		}
+	}
+
+	if s := p.Status(); s[0].Healthy {
+		t.Errorf("got %+v, want a unhealthy", s[0])
+	}
+
+	// a lags behind
+	a.stale, a.head = false, 5
+	p.check(ctx, time.Second)
+
+	for i := 0; i < 20; i++ {
+		blocks, _, err := FetchBlocks(ctx, p, []*big.Int{big.NewInt(8)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(blocks[0].Extra()) != "b" {
+			t.Fatalf("got block 8 of %s, want b", blocks[0].Extra())
+		}
+	}
+
+	if s := p.Status(); !s[0].Healthy {
+		t.Errorf("got %+v, want a healthy, not found isn't its fault", s[0])
+	}
+
+	// unknown to every endpoint
+	if _, err := p.BlockByNumber(ctx, big.NewInt(11)); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("got %v, want ethereum.NotFound", err)
+	}
+
+	// a missing block below its head is an answer
+	a.head = 10
+	p.check(ctx, time.Second)
+	a.head = 5
+
+	var notFound int
+	for i := 0; i < 20; i++ {
+		if _, err := p.BlockByNumber(ctx, big.NewInt(8)); errors.Is(err, ethereum.NotFound) {
+			notFound++
+		}
+	}
+
+	if notFound == 0 {
+		t.Errorf("got no block 8 not found, want a not to fail over")
+	}
+}
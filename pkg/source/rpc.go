@@ -0,0 +1,23 @@
+package source
+
+import (
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// RPC go-ethereum's ethclient over websocket or IPC, extended
+// with the calls ethclient does not expose, e.g. tracing.
+type RPC struct {
+	*ethclient.Client
+}
+
+// DialRPC
+func DialRPC(endpoint string) (*RPC, error) {
+	client, err := ethclient.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RPC{
+		Client: client,
+	}, nil
+}
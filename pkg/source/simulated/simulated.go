@@ -0,0 +1,22 @@
+package simulated
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// Backend go-ethereum's simulated backend as a chain source, for tests.
+type Backend struct {
+	*simulated.Backend
+	simulated.Client
+}
+
+// NewBackend
+func NewBackend(alloc types.GenesisAlloc) *Backend {
+	backend := simulated.NewBackend(alloc, simulated.WithBlockGasLimit(30_000_000))
+
+	return &Backend{
+		Backend: backend,
+		Client:  backend.Client(),
+	}
+}
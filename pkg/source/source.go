@@ -0,0 +1,80 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// ErrSubscriptionUnsupported returned by sources that can not push new heads.
+	ErrSubscriptionUnsupported = errors.New("source: subscriptions not supported")
+
+	// ErrCallUnsupported returned by sources without state to run calls against.
+	ErrCallUnsupported = errors.New("source: calls not supported")
+
+	// ErrStateUnsupported returned by sources without account state.
+	ErrStateUnsupported = errors.New("source: account state not supported")
+
+	// ErrReceiptUnsupported returned by sources without receipts,
+	// their txs are indexed without one.
+	ErrReceiptUnsupported = errors.New("source: receipts not supported")
+)
+
+// ChainSource chain data consumed by the indexer
+type ChainSource interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+var (
+	_ ChainSource = (*ethclient.Client)(nil)
+	_ ChainSource = (*RPC)(nil)
+	_ ChainSource = (*HTTP)(nil)
+	_ ChainSource = (*Replay)(nil)
+)
+
+// Dial picks a source by the endpoint scheme:
+// http(s) - plain JSON-RPC over HTTP, e.g. https://mainnet.infura.io/v3/{api_key}
+// file - replay a `geth export` file, e.g. file://./tmp/blocks.rlp?chain_id=1
+// otherwise ws(s) & ipc through go-ethereum's ethclient.
+func Dial(endpoint string) (ChainSource, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return NewHTTP(endpoint), nil
+	case "file":
+		chainID := big.NewInt(1)
+		if v := u.Query().Get("chain_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid chain_id %q", v)
+			}
+			chainID = big.NewInt(id)
+		}
+
+		return NewReplay(u.Host+u.Path, chainID)
+	default:
+		return DialRPC(endpoint)
+	}
+}
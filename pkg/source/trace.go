@@ -0,0 +1,92 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Tracer sources exposing the `debug` namespace, not every node does.
+type Tracer interface {
+	// TraceBlockCalls returns the call tree of every transaction of the
+	// block with hash, in transaction order, traced with geth's `callTracer`.
+	// Traced by hash, so every endpoint traces the same block.
+	TraceBlockCalls(ctx context.Context, hash common.Hash) ([]*CallFrame, error)
+}
+
+var (
+	_ Tracer = (*HTTP)(nil)
+	_ Tracer = (*RPC)(nil)
+)
+
+// CallFrame a call as reported by geth's `callTracer`
+type CallFrame struct {
+	Type    string          `json:"type"` // CALL, STATICCALL, DELEGATECALL, CREATE ...
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"` // nil for calls without value, e.g. STATICCALL
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error"`
+	Calls   []*CallFrame    `json:"calls"`
+
+	// TxHash of the traced tx, on root frames only,
+	// zero when the node doesn't report it.
+	TxHash common.Hash `json:"-"`
+}
+
+// callTracer `debug_traceBlockByHash` options
+var callTracer = map[string]any{
+	"tracer": "callTracer",
+}
+
+// traceResult
+type traceResult struct {
+	TxHash common.Hash `json:"txHash"`
+	Result *CallFrame  `json:"result"`
+	Error  string      `json:"error"`
+}
+
+// callFrames
+func callFrames(results []*traceResult) ([]*CallFrame, error) {
+	var frames = make([]*CallFrame, 0, len(results))
+
+	for i, r := range results {
+		if r.Error != "" {
+			return nil, fmt.Errorf("trace tx %d: %s", i, r.Error)
+		}
+
+		if r.Result == nil {
+			return nil, fmt.Errorf("trace tx %d: empty result", i)
+		}
+
+		r.Result.TxHash = r.TxHash
+		frames = append(frames, r.Result)
+	}
+
+	return frames, nil
+}
+
+// TraceBlockCalls executes `debug_traceBlockByHash` with the `callTracer`.
+func (h *HTTP) TraceBlockCalls(ctx context.Context, hash common.Hash) ([]*CallFrame, error) {
+	var results []*traceResult
+	if err := h.call(ctx, &results, "debug_traceBlockByHash", hash, callTracer); err != nil {
+		return nil, err
+	}
+
+	return callFrames(results)
+}
+
+// TraceBlockCalls executes `debug_traceBlockByHash` with the `callTracer`.
+func (r *RPC) TraceBlockCalls(ctx context.Context, hash common.Hash) ([]*CallFrame, error) {
+	var results []*traceResult
+	if err := r.Client.Client().CallContext(ctx, &results, "debug_traceBlockByHash", hash, callTracer); err != nil {
+		return nil, err
+	}
+
+	return callFrames(results)
+}
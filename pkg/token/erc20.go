@@ -0,0 +1,105 @@
+package token
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/twiny/blockscan/pkg/chain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TransferTopic `Transfer(address,address,uint256)` event signature,
+// shared by ERC-20 & ERC-721.
+var TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// ERC-20 metadata methods selectors
+var (
+	nameSelector     = crypto.Keccak256([]byte("name()"))[:4]
+	symbolSelector   = crypto.Keccak256([]byte("symbol()"))[:4]
+	decimalsSelector = crypto.Keccak256([]byte("decimals()"))[:4]
+)
+
+// DecodeERC20Transfer decodes an ERC-20 `Transfer` log,
+// returns false if l is not one. ERC-721 transfers
+// share the signature but index the token id.
+func DecodeERC20Transfer(l *chain.Log) (*chain.TokenTransfer, bool) {
+	if len(l.Topics) != 3 || !strings.EqualFold(l.Topics[0], TransferTopic.Hex()) {
+		return nil, false
+	}
+
+	data, err := hexutil.Decode(l.Data)
+	if err != nil || len(data) != 32 {
+		return nil, false
+	}
+
+	return &chain.TokenTransfer{
+		TxHash:      l.TxHash,
+		BlockNumber: l.BlockNumber,
+		LogIndex:    l.Index,
+		Token:       l.Address,
+		From:        topicToAddress(l.Topics[1]),
+		To:          topicToAddress(l.Topics[2]),
+		Amount:      new(big.Int).SetBytes(data).String(),
+	}, true
+}
+
+// Metadata calls `name()`, `symbol()` & `decimals()` of an ERC-20 token,
+// a field is left empty when its call fails, e.g. not implemented.
+func Metadata(ctx context.Context, caller ethereum.ContractCaller, address common.Address) *chain.Token {
+	t := &chain.Token{
+		Address: address.Hex(),
+	}
+
+	if out, err := call(ctx, caller, address, nameSelector); err == nil {
+		t.Name = decodeString(out)
+	}
+
+	if out, err := call(ctx, caller, address, symbolSelector); err == nil {
+		t.Symbol = decodeString(out)
+	}
+
+	if out, err := call(ctx, caller, address, decimalsSelector); err == nil && len(out) == 32 {
+		if d := new(big.Int).SetBytes(out); d.IsUint64() && d.Uint64() <= 255 {
+			t.Decimals = uint8(d.Uint64())
+		}
+	}
+
+	return t
+}
+
+// call
+func call(ctx context.Context, caller ethereum.ContractCaller, address common.Address, data []byte) ([]byte, error) {
+	return caller.CallContract(ctx, ethereum.CallMsg{
+		To:   &address,
+		Data: data,
+	}, nil)
+}
+
+// decodeString decodes an ABI string, or a bytes32 as
+// returned by some early tokens, e.g. MKR.
+func decodeString(out []byte) string {
+	if len(out) == 32 {
+		return strings.TrimRight(string(out), "\x00")
+	}
+
+	stringType, _ := abi.NewType("string", "", nil)
+
+	values, err := abi.Arguments{{Type: stringType}}.Unpack(out)
+	if err != nil || len(values) != 1 {
+		return ""
+	}
+
+	s, _ := values[0].(string)
+	return s
+}
+
+// topicToAddress
+func topicToAddress(topic string) string {
+	return common.HexToAddress(topic).Hex()
+}
@@ -0,0 +1,81 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// TestDecodeERC20Transfer
+func TestDecodeERC20Transfer(t *testing.T) {
+	from := "0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	to := "0x000000000000000000000000dac17f958d2ee523a2206206994597c13d831ec7"
+
+	tests := []struct {
+		name string
+		log  *chain.Log
+		ok   bool
+	}{
+		{
+			name: "TestTransfer",
+			log: &chain.Log{
+				Address: "0x6B175474E89094C44Da98b954EedeAC495271d0F",
+				Topics:  []string{TransferTopic.Hex(), from, to},
+				Data:    "0x00000000000000000000000000000000000000000000000000000000000003e8",
+			},
+			ok: true,
+		},
+		{
+			name: "TestERC721Transfer",
+			log: &chain.Log{
+				Topics: []string{TransferTopic.Hex(), from, to, "0x01"},
+				Data:   "0x",
+			},
+		},
+		{
+			name: "TestOtherEvent",
+			log: &chain.Log{
+				Topics: []string{"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925", from, to},
+				Data:   "0x00000000000000000000000000000000000000000000000000000000000003e8",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transfer, ok := DecodeERC20Transfer(tc.log)
+			if ok != tc.ok {
+				t.Fatalf("got ok %v, want %v", ok, tc.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if transfer.From != "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" ||
+				transfer.To != "0xdAC17F958D2ee523a2206206994597C13D831ec7" ||
+				transfer.Amount != "1000" ||
+				transfer.Token != tc.log.Address {
+				t.Errorf("unexpected transfer %+v", transfer)
+			}
+		})
+	}
+}
+
+// TestDecodeString
+func TestDecodeString(t *testing.T) {
+	// bytes32 "MKR"
+	bytes32 := append([]byte("MKR"), make([]byte, 29)...)
+	if s := decodeString(bytes32); s != "MKR" {
+		t.Errorf("got %q, want MKR", s)
+	}
+
+	// abi encoded "Dai"
+	encoded := make([]byte, 96)
+	encoded[31] = 0x20
+	encoded[63] = 3
+	copy(encoded[64:], "Dai")
+	if s := decodeString(encoded); s != "Dai" {
+		t.Errorf("got %q, want Dai", s)
+	}
+}
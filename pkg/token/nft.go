@@ -0,0 +1,123 @@
+package token
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/twiny/blockscan/pkg/chain"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ERC-1155 events signatures
+var (
+	TransferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	TransferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// DecodeNFTTransfers decodes an ERC-721 `Transfer` or an ERC-1155
+// `TransferSingle`/`TransferBatch` log, nil if l is none of them.
+func DecodeNFTTransfers(l *chain.Log) []*chain.NFTTransfer {
+	if len(l.Topics) != 4 {
+		return nil
+	}
+
+	data, err := hexutil.Decode(l.Data)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case strings.EqualFold(l.Topics[0], TransferTopic.Hex()):
+		if len(data) != 0 {
+			return nil
+		}
+
+		return []*chain.NFTTransfer{{
+			TxHash:      l.TxHash,
+			BlockNumber: l.BlockNumber,
+			LogIndex:    l.Index,
+			Standard:    chain.ERC721,
+			Collection:  l.Address,
+			From:        topicToAddress(l.Topics[1]),
+			To:          topicToAddress(l.Topics[2]),
+			TokenID:     topicToInt(l.Topics[3]).String(),
+			Quantity:    "1",
+		}}
+
+	case strings.EqualFold(l.Topics[0], TransferSingleTopic.Hex()):
+		if len(data) != 64 {
+			return nil
+		}
+
+		return []*chain.NFTTransfer{{
+			TxHash:      l.TxHash,
+			BlockNumber: l.BlockNumber,
+			LogIndex:    l.Index,
+			Standard:    chain.ERC1155,
+			Collection:  l.Address,
+			Operator:    topicToAddress(l.Topics[1]),
+			From:        topicToAddress(l.Topics[2]),
+			To:          topicToAddress(l.Topics[3]),
+			TokenID:     new(big.Int).SetBytes(data[:32]).String(),
+			Quantity:    new(big.Int).SetBytes(data[32:]).String(),
+		}}
+
+	case strings.EqualFold(l.Topics[0], TransferBatchTopic.Hex()):
+		ids, values, ok := decodeBatch(data)
+		if !ok {
+			return nil
+		}
+
+		var transfers = make([]*chain.NFTTransfer, 0, len(ids))
+		for i := range ids {
+			transfers = append(transfers, &chain.NFTTransfer{
+				TxHash:      l.TxHash,
+				BlockNumber: l.BlockNumber,
+				LogIndex:    l.Index,
+				BatchIndex:  i,
+				Standard:    chain.ERC1155,
+				Collection:  l.Address,
+				Operator:    topicToAddress(l.Topics[1]),
+				From:        topicToAddress(l.Topics[2]),
+				To:          topicToAddress(l.Topics[3]),
+				TokenID:     ids[i].String(),
+				Quantity:    values[i].String(),
+			})
+		}
+
+		return transfers
+	}
+
+	return nil
+}
+
+// decodeBatch decodes `TransferBatch` ids & values arrays.
+func decodeBatch(data []byte) ([]*big.Int, []*big.Int, bool) {
+	arrayType, _ := abi.NewType("uint256[]", "", nil)
+
+	values, err := abi.Arguments{{Type: arrayType}, {Type: arrayType}}.Unpack(data)
+	if err != nil || len(values) != 2 {
+		return nil, nil, false
+	}
+
+	ids, ok := values[0].([]*big.Int)
+	if !ok {
+		return nil, nil, false
+	}
+
+	amounts, ok := values[1].([]*big.Int)
+	if !ok || len(ids) != len(amounts) {
+		return nil, nil, false
+	}
+
+	return ids, amounts, true
+}
+
+// topicToInt
+func topicToInt(topic string) *big.Int {
+	b, _ := hexutil.Decode(topic)
+	return new(big.Int).SetBytes(b)
+}
@@ -0,0 +1,93 @@
+package token
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/twiny/blockscan/pkg/chain"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TestDecodeNFTTransfers
+func TestDecodeNFTTransfers(t *testing.T) {
+	operator := "0x0000000000000000000000000000000000000000000000000000000000000001"
+	from := "0x0000000000000000000000000000000000000000000000000000000000000002"
+	to := "0x0000000000000000000000000000000000000000000000000000000000000003"
+
+	arrayType, _ := abi.NewType("uint256[]", "", nil)
+	batch, err := abi.Arguments{{Type: arrayType}, {Type: arrayType}}.Pack(
+		[]*big.Int{big.NewInt(7), big.NewInt(8)},
+		[]*big.Int{big.NewInt(1), big.NewInt(5)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		log        *chain.Log
+		standard   string
+		tokenIDs   []string
+		quantities []string
+	}{
+		{
+			name: "TestERC721",
+			log: &chain.Log{
+				Topics: []string{TransferTopic.Hex(), from, to, "0x000000000000000000000000000000000000000000000000000000000000002a"},
+				Data:   "0x",
+			},
+			standard:   chain.ERC721,
+			tokenIDs:   []string{"42"},
+			quantities: []string{"1"},
+		},
+		{
+			name: "TestTransferSingle",
+			log: &chain.Log{
+				Topics: []string{TransferSingleTopic.Hex(), operator, from, to},
+				Data:   "0x000000000000000000000000000000000000000000000000000000000000002a0000000000000000000000000000000000000000000000000000000000000003",
+			},
+			standard:   chain.ERC1155,
+			tokenIDs:   []string{"42"},
+			quantities: []string{"3"},
+		},
+		{
+			name: "TestTransferBatch",
+			log: &chain.Log{
+				Topics: []string{TransferBatchTopic.Hex(), operator, from, to},
+				Data:   hexutil.Encode(batch),
+			},
+			standard:   chain.ERC1155,
+			tokenIDs:   []string{"7", "8"},
+			quantities: []string{"1", "5"},
+		},
+		{
+			name: "TestERC20",
+			log: &chain.Log{
+				Topics: []string{TransferTopic.Hex(), from, to},
+				Data:   "0x00000000000000000000000000000000000000000000000000000000000003e8",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transfers := DecodeNFTTransfers(tc.log)
+			if len(transfers) != len(tc.tokenIDs) {
+				t.Fatalf("got %d transfers, want %d", len(transfers), len(tc.tokenIDs))
+			}
+
+			for i, transfer := range transfers {
+				if transfer.Standard != tc.standard ||
+					transfer.TokenID != tc.tokenIDs[i] ||
+					transfer.Quantity != tc.quantities[i] ||
+					transfer.BatchIndex != i ||
+					transfer.From != "0x0000000000000000000000000000000000000002" ||
+					transfer.To != "0x0000000000000000000000000000000000000003" {
+					t.Errorf("unexpected transfer %+v", transfer)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/shopspring/decimal"
 )
 
@@ -18,6 +19,21 @@ func AddrToHex(addr *common.Address) string {
 	return addr.Hex()
 }
 
+// EffectiveGasPrice price per gas paid by tx in a block with baseFee,
+// `min(gasFeeCap, baseFee + gasTipCap)`, the gas price for legacy transactions.
+func EffectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+
+	return price
+}
+
 // IsZeroAddress validate if it's a 0 address
 func IsZeroAddress(iaddress interface{}) bool {
 	var address common.Address
@@ -0,0 +1,84 @@
+package utils
+
+import "math/big"
+
+// Holder
+type Holder struct {
+	Address string
+	Balance *big.Int
+}
+
+// Ledger derives balances from a sequence of transfers,
+// the zero address (mint & burn) is not tracked.
+type Ledger struct {
+	balances map[string]*big.Int
+	order    []string // addresses by first appearance
+}
+
+// NewLedger
+func NewLedger() *Ledger {
+	return &Ledger{
+		balances: map[string]*big.Int{},
+		order:    []string{},
+	}
+}
+
+// Transfer moves amount, a base 10 string, from -> to.
+func (l *Ledger) Transfer(from, to, amount string) {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return
+	}
+
+	l.add(from, new(big.Int).Neg(value))
+	l.add(to, value)
+}
+
+// add
+func (l *Ledger) add(address string, value *big.Int) {
+	if IsZeroAddress(address) {
+		return
+	}
+
+	balance, found := l.balances[address]
+	if !found {
+		balance = new(big.Int)
+		l.balances[address] = balance
+		l.order = append(l.order, address)
+	}
+
+	balance.Add(balance, value)
+}
+
+// Holders addresses with a positive balance, by first appearance.
+func (l *Ledger) Holders() []Holder {
+	var holders = []Holder{}
+
+	for _, address := range l.order {
+		if balance := l.balances[address]; balance.Sign() > 0 {
+			holders = append(holders, Holder{
+				Address: address,
+				Balance: balance,
+			})
+		}
+	}
+
+	return holders
+}
+
+// Balances addresses with a non zero balance, negative
+// ones included, by first appearance.
+func (l *Ledger) Balances() []Holder {
+	var holders = []Holder{}
+
+	for _, address := range l.order {
+		if balance := l.balances[address]; balance.Sign() != 0 {
+			holders = append(holders, Holder{
+				Address: address,
+				Balance: balance,
+			})
+		}
+	}
+
+	return holders
+}
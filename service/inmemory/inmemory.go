@@ -1,9 +1,925 @@
 package inmemory
 
-import "sync"
+import (
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
 
-// InMemory -
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/utils"
+)
+
+// ErrDuplicate a block, tx or contract already saved.
+var ErrDuplicate = errors.New("duplicate key")
+
+// InMemory a store keeping everything in memory, for tests, demos &
+// ephemeral runs. Not found lookups return `sql.ErrNoRows` as the
+// database stores do. Saved & returned values must not be modified.
 type InMemory struct {
-	mu *sync.Mutex
-	db map[string]any
+	mu *sync.RWMutex
+	db *data
+	//
+	numbers   []int64                      // of saved blocks, ascending
+	txs       map[string]*chain.Tx         // by hash
+	contracts map[string][]*chain.Contract // deployments by address
+	//
+	snapshot string // file written on close, empty disables it
+	dirty    bool   // modified since loaded
+}
+
+// data the snapshot content, indexes are rebuilt on load.
+type data struct {
+	Blocks     map[int64]*chain.BlockBatch
+	Tokens     map[string]*chain.Token
+	ScanRanges []*chain.ScanRange
+}
+
+// NewInMemory loads snapshot when it exists, it is
+// written back on `Close`. example: ./tmp/store.gob
+func NewInMemory(snapshot string) (*InMemory, error) {
+	m := &InMemory{
+		mu: &sync.RWMutex{},
+		db: &data{
+			Blocks:     map[int64]*chain.BlockBatch{},
+			Tokens:     map[string]*chain.Token{},
+			ScanRanges: []*chain.ScanRange{},
+		},
+		txs:       map[string]*chain.Tx{},
+		contracts: map[string][]*chain.Contract{},
+		snapshot:  snapshot,
+	}
+
+	if snapshot == "" {
+		return m, nil
+	}
+
+	f, err := os.Open(snapshot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(m.db); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", snapshot, err)
+	}
+
+	for _, bb := range m.db.Blocks {
+		m.index(bb)
+	}
+
+	return m, nil
+}
+
+// Ping
+func (m *InMemory) Ping() error {
+	return nil
+}
+
+// index adds the number, txs & contracts of bb to the lookups.
+func (m *InMemory) index(bb *chain.BlockBatch) {
+	if i, found := slices.BinarySearch(m.numbers, bb.Block.Number); !found {
+		m.numbers = slices.Insert(m.numbers, i, bb.Block.Number)
+	}
+
+	for _, t := range bb.Txs {
+		m.txs[t.Hash] = t
+	}
+
+	for _, c := range bb.Contracts {
+		m.contracts[c.Address] = append(m.contracts[c.Address], c)
+	}
+}
+
+// blocks saved blocks in range [i, j], by number.
+func (m *InMemory) blocks(i, j int64) []*chain.BlockBatch {
+	var blocks = []*chain.BlockBatch{}
+
+	lo, hi := m.span(i, j)
+	for _, n := range m.numbers[lo:hi] {
+		blocks = append(blocks, m.db.Blocks[n])
+	}
+
+	return blocks
+}
+
+// span the bounds in numbers of the blocks in range [i, j].
+func (m *InMemory) span(i, j int64) (int, int) {
+	lo, _ := slices.BinarySearch(m.numbers, i)
+	hi, found := slices.BinarySearch(m.numbers[lo:], j)
+	if found {
+		hi++
+	}
+
+	return lo, lo + hi
+}
+
+// latest the highest block, timestamps increase
+// with the height, nil when empty.
+func (m *InMemory) latest() *chain.BlockBatch {
+	if len(m.numbers) == 0 {
+		return nil
+	}
+
+	return m.db.Blocks[m.numbers[len(m.numbers)-1]]
+}
+
+// Rest service \\
+
+// GetLatestBlock
+func (m *InMemory) GetLatestBlock(ctx context.Context) (*chain.Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bb := m.latest()
+	if bb == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return blockDetails(bb), nil
+}
+
+// GetBlock
+func (m *InMemory) GetBlock(ctx context.Context, n int64) (*chain.Block, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bb, found := m.db.Blocks[n]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+
+	return blockDetails(bb), nil
+}
+
+// blockDetails a copy of the block with its transactions hash & withdrawals.
+func blockDetails(bb *chain.BlockBatch) *chain.Block {
+	b := *bb.Block
+
+	b.Txs = make([]string, 0, len(bb.Txs))
+	for _, t := range bb.Txs {
+		b.Txs = append(b.Txs, t.Hash)
+	}
+
+	if len(b.Withdrawals) == 0 {
+		b.Withdrawals = nil
+	}
+
+	return &b
+}
+
+// GetAddressWithdrawals withdrawals to an address in range [i, j], newest first.
+func (m *InMemory) GetAddressWithdrawals(ctx context.Context, address string, i, j int64, limit int) ([]*chain.Withdrawal, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var withdrawals = []*chain.Withdrawal{}
+
+	for _, bb := range m.blocks(i, j) {
+		for _, w := range bb.Block.Withdrawals {
+			if w.Address == address {
+				withdrawals = append(withdrawals, w)
+			}
+		}
+	}
+
+	sort.Slice(withdrawals, func(a, b int) bool {
+		return withdrawals[a].Index > withdrawals[b].Index
+	})
+
+	return head(withdrawals, limit), nil
+}
+
+// GetLatestTx
+func (m *InMemory) GetLatestTx(ctx context.Context) (*chain.Tx, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bb := m.latest()
+	if bb == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	var latest *chain.Tx
+	for _, t := range bb.Txs {
+		if latest == nil || t.Order > latest.Order {
+			latest = t
+		}
+	}
+
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	t := *latest
+	return &t, nil
+}
+
+// GetTx
+func (m *InMemory) GetTx(ctx context.Context, hash string) (*chain.Tx, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tx, found := m.txs[hash]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+
+	t := *tx
+	return &t, nil
+}
+
+// GetAddressTxs transactions from and/or to an address, newest first.
+func (m *InMemory) GetAddressTxs(ctx context.Context, f *chain.AddressTxFilter) ([]*chain.Tx, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var txs = []*chain.Tx{}
+
+	for _, bb := range m.blocks(f.FromBlock, f.ToBlock) {
+		for _, tx := range bb.Txs {
+			switch f.Direction {
+			case chain.DirectionIn:
+				if tx.To != f.Address {
+					continue
+				}
+			case chain.DirectionOut:
+				if tx.From != f.Address {
+					continue
+				}
+			default:
+				if tx.From != f.Address && tx.To != f.Address {
+					continue
+				}
+			}
+
+			if c := f.Cursor; c != nil {
+				if tx.BlockNumber > c.BlockNumber || (tx.BlockNumber == c.BlockNumber && tx.Order >= c.Order) {
+					continue
+				}
+			}
+
+			// listed without details, as by the database stores
+			t := *tx
+			t.AccessList, t.BlobHashes, t.Receipt = nil, nil, nil
+			txs = append(txs, &t)
+		}
+	}
+
+	sort.Slice(txs, func(a, b int) bool {
+		if txs[a].BlockNumber != txs[b].BlockNumber {
+			return txs[a].BlockNumber > txs[b].BlockNumber
+		}
+		return txs[a].Order > txs[b].Order
+	})
+
+	return head(txs, f.Limit), nil
+}
+
+// GetInternalTxs calls traced in a transaction, in trace order.
+func (m *InMemory) GetInternalTxs(ctx context.Context, hash string) ([]*chain.InternalTx, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var txs = []*chain.InternalTx{}
+
+	tx, found := m.txs[hash]
+	if !found {
+		return txs, nil
+	}
+
+	bb, found := m.db.Blocks[tx.BlockNumber]
+	if !found {
+		return txs, nil
+	}
+
+	for _, t := range bb.InternalTxs {
+		if t.TxHash == hash {
+			txs = append(txs, t)
+		}
+	}
+
+	sort.Slice(txs, func(a, b int) bool {
+		return txs[a].Index < txs[b].Index
+	})
+
+	return txs, nil
+}
+
+// GetAddressInternalTxs calls from or to an address in range [i, j], newest first.
+func (m *InMemory) GetAddressInternalTxs(ctx context.Context, address string, i, j int64, limit int) ([]*chain.InternalTx, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var txs = []*chain.InternalTx{}
+
+	for _, bb := range m.blocks(i, j) {
+		for _, t := range bb.InternalTxs {
+			if t.From == address || t.To == address {
+				txs = append(txs, t)
+			}
+		}
+	}
+
+	sort.Slice(txs, func(a, b int) bool {
+		if txs[a].BlockNumber != txs[b].BlockNumber {
+			return txs[a].BlockNumber > txs[b].BlockNumber
+		}
+		if txs[a].TxHash != txs[b].TxHash {
+			return txs[a].TxHash > txs[b].TxHash
+		}
+		return txs[a].Index > txs[b].Index
+	})
+
+	return head(txs, limit), nil
+}
+
+// GetLogs
+func (m *InMemory) GetLogs(ctx context.Context, f *chain.LogFilter) ([]*chain.Log, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var logs = []*chain.Log{}
+
+	for _, bb := range m.blocks(f.FromBlock, f.ToBlock) {
+		for _, t := range bb.Txs {
+			if t.Receipt == nil {
+				continue
+			}
+
+			for _, l := range t.Receipt.Logs {
+				if matchLog(l, f) {
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+
+	sort.Slice(logs, func(a, b int) bool {
+		if logs[a].BlockNumber != logs[b].BlockNumber {
+			return logs[a].BlockNumber < logs[b].BlockNumber
+		}
+		return logs[a].Index < logs[b].Index
+	})
+
+	return head(logs, f.Limit), nil
+}
+
+// matchLog
+func matchLog(l *chain.Log, f *chain.LogFilter) bool {
+	if len(f.Addresses) > 0 && !contains(f.Addresses, l.Address) {
+		return false
+	}
+
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+
+		if i >= len(l.Topics) || !contains(topics, l.Topics[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetToken
+func (m *InMemory) GetToken(ctx context.Context, address string) (*chain.Token, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, found := m.db.Tokens[address]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+
+	token := *t
+	return &token, nil
+}
+
+// GetTokenTransfers transfers of a token in range [i, j], newest first.
+func (m *InMemory) GetTokenTransfers(ctx context.Context, token string, i, j int64, limit int) ([]*chain.TokenTransfer, error) {
+	return m.tokenTransfers(i, j, limit, func(t *chain.TokenTransfer) bool {
+		return t.Token == token
+	}), nil
+}
+
+// GetAddressTokenTransfers transfers from or to an address in range [i, j], newest first.
+func (m *InMemory) GetAddressTokenTransfers(ctx context.Context, address string, i, j int64, limit int) ([]*chain.TokenTransfer, error) {
+	return m.tokenTransfers(i, j, limit, func(t *chain.TokenTransfer) bool {
+		return t.From == address || t.To == address
+	}), nil
+}
+
+// tokenTransfers matching transfers in range [i, j], newest first.
+func (m *InMemory) tokenTransfers(i, j int64, limit int, match func(*chain.TokenTransfer) bool) []*chain.TokenTransfer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var transfers = []*chain.TokenTransfer{}
+
+	for _, bb := range m.blocks(i, j) {
+		for _, t := range bb.TokenTransfers {
+			if match(t) {
+				transfers = append(transfers, t)
+			}
+		}
+	}
+
+	sort.Slice(transfers, func(a, b int) bool {
+		if transfers[a].BlockNumber != transfers[b].BlockNumber {
+			return transfers[a].BlockNumber > transfers[b].BlockNumber
+		}
+		return transfers[a].LogIndex > transfers[b].LogIndex
+	})
+
+	return head(transfers, limit)
+}
+
+// GetNFTTransfers newest first.
+func (m *InMemory) GetNFTTransfers(ctx context.Context, f *chain.NFTTransferFilter) ([]*chain.NFTTransfer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var transfers = []*chain.NFTTransfer{}
+
+	for _, bb := range m.blocks(f.FromBlock, f.ToBlock) {
+		for _, t := range bb.NFTTransfers {
+			if f.Collection != "" && t.Collection != f.Collection {
+				continue
+			}
+
+			if f.TokenID != "" && t.TokenID != f.TokenID {
+				continue
+			}
+
+			if f.Address != "" && t.From != f.Address && t.To != f.Address {
+				continue
+			}
+
+			transfers = append(transfers, t)
+		}
+	}
+
+	sortNFTTransfers(transfers)
+
+	// newest first
+	for a, b := 0, len(transfers)-1; a < b; a, b = a+1, b-1 {
+		transfers[a], transfers[b] = transfers[b], transfers[a]
+	}
+
+	return head(transfers, f.Limit), nil
+}
+
+// GetNFTOwners replays the transfers of a token to derive its current holders.
+func (m *InMemory) GetNFTOwners(ctx context.Context, collection, tokenID string) ([]*chain.NFTOwner, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var transfers = []*chain.NFTTransfer{}
+
+	for _, bb := range m.db.Blocks {
+		for _, t := range bb.NFTTransfers {
+			if t.Collection == collection && t.TokenID == tokenID {
+				transfers = append(transfers, t)
+			}
+		}
+	}
+
+	sortNFTTransfers(transfers)
+
+	var ledger = utils.NewLedger()
+	for _, t := range transfers {
+		ledger.Transfer(t.From, t.To, t.Quantity)
+	}
+
+	var owners = []*chain.NFTOwner{}
+	for _, h := range ledger.Holders() {
+		owners = append(owners, &chain.NFTOwner{
+			Address:  h.Address,
+			Quantity: h.Balance.String(),
+		})
+	}
+
+	return owners, nil
+}
+
+// sortNFTTransfers oldest first.
+func sortNFTTransfers(transfers []*chain.NFTTransfer) {
+	sort.Slice(transfers, func(a, b int) bool {
+		x, y := transfers[a], transfers[b]
+		if x.BlockNumber != y.BlockNumber {
+			return x.BlockNumber < y.BlockNumber
+		}
+		if x.LogIndex != y.LogIndex {
+			return x.LogIndex < y.LogIndex
+		}
+		return x.BatchIndex < y.BatchIndex
+	})
+}
+
+// GetBalance sums the balance deltas of an address up to block n,
+// every stored block is visited on each call.
+func (m *InMemory) GetBalance(ctx context.Context, address string, n int64) (*chain.Balance, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var balance = new(big.Int)
+
+	for number, bb := range m.db.Blocks {
+		if number > n {
+			continue
+		}
+
+		for _, d := range bb.BalanceDeltas {
+			if d.Address != address {
+				continue
+			}
+
+			value, ok := new(big.Int).SetString(d.Delta, 10)
+			if !ok {
+				return nil, fmt.Errorf("address %s: invalid balance delta %q", address, d.Delta)
+			}
+
+			balance.Add(balance, value)
+		}
+	}
+
+	return &chain.Balance{
+		Address:     address,
+		BlockNumber: n,
+		Balance:     balance.String(),
+	}, nil
+}
+
+// GetBalanceAddresses a random sample of addresses
+// which balance changed in range [i, j].
+func (m *InMemory) GetBalanceAddresses(ctx context.Context, i, j int64, limit int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		addresses = []string{}
+		seen      = map[string]bool{}
+	)
+
+	for _, bb := range m.blocks(i, j) {
+		for _, d := range bb.BalanceDeltas {
+			if !seen[d.Address] {
+				seen[d.Address] = true
+				addresses = append(addresses, d.Address)
+			}
+		}
+	}
+
+	rand.Shuffle(len(addresses), func(a, b int) {
+		addresses[a], addresses[b] = addresses[b], addresses[a]
+	})
+
+	return head(addresses, limit), nil
+}
+
+// GetContract the latest deployment at address.
+func (m *InMemory) GetContract(ctx context.Context, address string) (*chain.Contract, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var latest *chain.Contract
+	for _, c := range m.contracts[address] {
+		if latest == nil || c.BlockNumber > latest.BlockNumber {
+			latest = c
+		}
+	}
+
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	contract := *latest
+	return &contract, nil
+}
+
+// GetDeployerContracts contracts deployed by an address in range [i, j], newest first.
+func (m *InMemory) GetDeployerContracts(ctx context.Context, deployer string, i, j int64, limit int) ([]*chain.Contract, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var contracts = []*chain.Contract{}
+
+	blocks := m.blocks(i, j)
+	for k := len(blocks) - 1; k >= 0; k-- {
+		for _, c := range blocks[k].Contracts {
+			if c.Deployer == deployer {
+				contracts = append(contracts, c)
+			}
+		}
+	}
+
+	return head(contracts, limit), nil
+}
+
+// GetStats
+func (m *InMemory) GetStats(ctx context.Context, i, j int64) (*chain.Stats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		status = &chain.Stats{Txs: []string{}}
+		total  = new(big.Int)
+	)
+
+	for _, bb := range m.blocks(i, j) {
+		for _, t := range bb.Txs {
+			value, ok := new(big.Int).SetString(t.Amount, 10)
+			if !ok {
+				return nil, fmt.Errorf("tx %s: invalid amount %q", t.Hash, t.Amount)
+			}
+
+			total.Add(total, value)
+
+			status.Txs = append(status.Txs, t.Hash)
+		}
+	}
+
+	status.TotalAmount = total.String()
+
+	return status, nil
+}
+
+// GetGaps returns ranges of missing blocks in range [i, j]
+func (m *InMemory) GetGaps(ctx context.Context, i, j int64) ([]*chain.Gap, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var (
+		gaps = []*chain.Gap{}
+		next = i // first block not seen yet
+	)
+
+	for _, bb := range m.blocks(i, j) {
+		if n := bb.Block.Number; n > next {
+			gaps = append(gaps, &chain.Gap{From: next, To: n - 1})
+		}
+		next = bb.Block.Number + 1
+	}
+
+	if next <= j {
+		gaps = append(gaps, &chain.Gap{From: next, To: j})
+	}
+
+	return gaps, nil
+}
+
+// Indexer service \\
+
+// HasScanned
+func (m *InMemory) HasScanned(ctx context.Context, id int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, found := m.db.Blocks[id]
+	return found
+}
+
+// GetBlockHash
+func (m *InMemory) GetBlockHash(ctx context.Context, id int64) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bb, found := m.db.Blocks[id]
+	if !found {
+		return "", sql.ErrNoRows
+	}
+
+	return bb.Block.Hash, nil
+}
+
+// GetTotalDifficulty of block id, empty when unknown.
+func (m *InMemory) GetTotalDifficulty(ctx context.Context, id int64) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bb, found := m.db.Blocks[id]
+	if !found {
+		return "", sql.ErrNoRows
+	}
+
+	return bb.Block.TotalDifficulty, nil
+}
+
+// SetTotalDifficulty of block id, e.g. once its parent's is known.
+func (m *InMemory) SetTotalDifficulty(ctx context.Context, id int64, td string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if bb, found := m.db.Blocks[id]; found {
+		bb.Block.TotalDifficulty = td
+		m.dirty = true
+	}
+
+	return nil
+}
+
+// DeleteBlocks deletes blocks in range [i, j] along with their transactions.
+func (m *InMemory) DeleteBlocks(ctx context.Context, i, j int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, bb := range m.blocks(i, j) {
+		for _, t := range bb.Txs {
+			delete(m.txs, t.Hash)
+		}
+
+		for _, c := range bb.Contracts {
+			m.contracts[c.Address] = slices.DeleteFunc(m.contracts[c.Address], func(d *chain.Contract) bool {
+				return d.TxHash == c.TxHash
+			})
+
+			if len(m.contracts[c.Address]) == 0 {
+				delete(m.contracts, c.Address)
+			}
+		}
+
+		delete(m.db.Blocks, bb.Block.Number)
+		m.dirty = true
+	}
+
+	lo, hi := m.span(i, j)
+	m.numbers = slices.Delete(m.numbers, lo, hi)
+
+	return nil
+}
+
+// SaveBlockBatch saves a block along with its transactions, receipts, logs,
+// internal txs, contracts, token metadata, transfers & balance changes at once.
+// Nothing is saved if the block, a tx or a contract already exists.
+func (m *InMemory) SaveBlockBatch(ctx context.Context, bb *chain.BlockBatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.db.Blocks[bb.Block.Number]; found {
+		return fmt.Errorf("block %d: %w", bb.Block.Number, ErrDuplicate)
+	}
+
+	var txs = map[string]bool{}
+	for _, t := range bb.Txs {
+		if _, found := m.txs[t.Hash]; found || txs[t.Hash] {
+			return fmt.Errorf("tx %s: %w", t.Hash, ErrDuplicate)
+		}
+		txs[t.Hash] = true
+	}
+
+	// an address is deployed again after a self-destruct
+	var contracts = map[string]bool{}
+	for _, c := range bb.Contracts {
+		key := c.Address + c.TxHash
+		if contracts[key] || slices.ContainsFunc(m.contracts[c.Address], func(d *chain.Contract) bool {
+			return d.TxHash == c.TxHash
+		}) {
+			return fmt.Errorf("contract %s: %w", c.Address, ErrDuplicate)
+		}
+		contracts[key] = true
+	}
+
+	m.db.Blocks[bb.Block.Number] = bb
+	m.index(bb)
+	m.dirty = true
+
+	// unless already saved, e.g. by another writer
+	for _, t := range bb.Tokens {
+		if _, found := m.db.Tokens[t.Address]; !found {
+			m.db.Tokens[t.Address] = t
+		}
+	}
+
+	return nil
+}
+
+// HasToken
+func (m *InMemory) HasToken(ctx context.Context, address string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, found := m.db.Tokens[address]
+	return found
+}
+
+// GetScanRanges
+func (m *InMemory) GetScanRanges(ctx context.Context) ([]*chain.ScanRange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ranges = make([]*chain.ScanRange, 0, len(m.db.ScanRanges))
+	for _, r := range m.db.ScanRanges {
+		c := *r
+		ranges = append(ranges, &c)
+	}
+
+	return ranges, nil
+}
+
+// SaveScanRange
+func (m *InMemory) SaveScanRange(ctx context.Context, r *chain.ScanRange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r.ID = 1
+	if n := len(m.db.ScanRanges); n > 0 {
+		r.ID = m.db.ScanRanges[n-1].ID + 1
+	}
+
+	c := *r
+	m.db.ScanRanges = append(m.db.ScanRanges, &c)
+	m.dirty = true
+
+	return nil
+}
+
+// UpdateScanRange
+func (m *InMemory) UpdateScanRange(ctx context.Context, r *chain.ScanRange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.db.ScanRanges {
+		if s.ID == r.ID {
+			s.End = r.End
+			s.Cursor = r.Cursor
+			s.Done = r.Done
+			m.dirty = true
+		}
+	}
+
+	return nil
+}
+
+// // \\ \\
+// Close writes the snapshot when enabled, unless nothing changed
+// since it was loaded, e.g. by a reader sharing the snapshot file.
+func (m *InMemory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.snapshot == "" || !m.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.snapshot), 0755); err != nil {
+		return err
+	}
+
+	// written aside then renamed, so a failed
+	// write keeps the previous snapshot.
+	tmp := m.snapshot + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewEncoder(f).Encode(m.db); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, m.snapshot); err != nil {
+		return err
+	}
+
+	m.dirty = false
+
+	return nil
+}
+
+// head the first n items of s, all when n is not positive.
+func head[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// contains
+func contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,201 @@
+package inmemory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/service/storetest"
+)
+
+// newTestBatch a block n with a tx from 0xa to 0xb & its balance changes.
+func newTestBatch(n int64, hash string) *chain.BlockBatch {
+	return &chain.BlockBatch{
+		Block: &chain.Block{Number: n, Hash: hash, Timestamp: time.Unix(n, 0)},
+		Txs: []*chain.Tx{
+			{Hash: hash, BlockNumber: n, From: "0xa", To: "0xb", Amount: "10", Timestamp: time.Unix(n, 0)},
+		},
+		BalanceDeltas: []*chain.BalanceDelta{
+			{BlockNumber: n, Address: "0xa", Delta: "-10"},
+			{BlockNumber: n, Address: "0xb", Delta: "10"},
+		},
+	}
+}
+
+// TestSaveBlockBatch
+func TestSaveBlockBatch(t *testing.T) {
+	m, err := NewInMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	for n := int64(1); n <= 3; n++ {
+		if err := m.SaveBlockBatch(ctx, newTestBatch(n, fmt.Sprintf("0x%d", n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := m.GetLatestBlock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Number != 3 || !reflect.DeepEqual(latest.Txs, []string{"0x3"}) {
+		t.Errorf("unexpected latest block %+v", latest)
+	}
+
+	balance, err := m.GetBalance(ctx, "0xb", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance.Balance != "20" {
+		t.Errorf("got balance %s, want 20", balance.Balance)
+	}
+
+	// duplicate tx fails the whole batch
+	if err := m.SaveBlockBatch(ctx, newTestBatch(4, "0x1")); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("got %v, want ErrDuplicate", err)
+	}
+
+	if m.HasScanned(ctx, 4) {
+		t.Error("block 4 saved, want rejected")
+	}
+
+	if err := m.DeleteBlocks(ctx, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.GetTx(ctx, "0x2"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("got %v, want sql.ErrNoRows", err)
+	}
+
+	gaps, err := m.GetGaps(ctx, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []*chain.Gap{{From: 2, To: 4}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("got gaps %+v, want %+v", gaps, want)
+	}
+
+	// latest tracked across deletes & out of order saves
+	if latest, err := m.GetLatestBlock(ctx); err != nil || latest.Number != 1 {
+		t.Errorf("got latest block %+v, %v, want 1", latest, err)
+	}
+
+	for _, n := range []int64{5, 3} {
+		if err := m.SaveBlockBatch(ctx, newTestBatch(n, fmt.Sprintf("0x%d", n))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if latest, err := m.GetLatestBlock(ctx); err != nil || latest.Number != 5 {
+		t.Errorf("got latest block %+v, %v, want 5", latest, err)
+	}
+
+	if got := m.blocks(2, 4); len(got) != 1 || got[0].Block.Number != 3 {
+		t.Errorf("got blocks %+v, want block 3", got)
+	}
+}
+
+// TestSnapshot
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.gob")
+
+	ctx := context.Background()
+
+	m, err := NewInMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SaveBlockBatch(ctx, newTestBatch(1, "0x1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.SaveScanRange(ctx, &chain.ScanRange{Start: 1, End: 10, Cursor: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = NewInMemory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := m.GetTx(ctx, "0x1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Amount != "10" || !tx.Timestamp.Equal(time.Unix(1, 0)) {
+		t.Errorf("unexpected tx %+v", tx)
+	}
+
+	ranges, err := m.GetScanRanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ranges) != 1 || ranges[0].ID != 1 || ranges[0].Cursor != 2 {
+		t.Errorf("unexpected scan ranges %+v", ranges)
+	}
+}
+
+// TestConcurrentWrites
+func TestConcurrentWrites(t *testing.T) {
+	m, err := NewInMemory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for n := int64(0); n < 50; n++ {
+		wg.Add(1)
+		go func(n int64) {
+			defer wg.Done()
+
+			if err := m.SaveBlockBatch(ctx, newTestBatch(n, fmt.Sprintf("0x%d", n))); err != nil {
+				t.Error(err)
+			}
+
+			m.GetStats(ctx, 0, n)
+		}(n)
+	}
+	wg.Wait()
+
+	stats, err := m.GetStats(ctx, 0, 49)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats.Txs) != 50 || stats.TotalAmount != "500" {
+		t.Errorf("got %d txs, total %s", len(stats.Txs), stats.TotalAmount)
+	}
+}
+
+// TestStore
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store {
+		m, err := NewInMemory("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	})
+}
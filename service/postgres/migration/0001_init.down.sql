@@ -0,0 +1,15 @@
+DROP TABLE scan_ranges;
+DROP TABLE balance_deltas;
+DROP TABLE contracts;
+DROP TABLE withdrawals;
+DROP TABLE nft_transfers;
+DROP TABLE token_transfers;
+DROP TABLE tokens;
+DROP TABLE logs;
+DROP TABLE receipts;
+DROP TABLE internal_txs;
+DROP TABLE blob_hashes;
+DROP TABLE access_list_keys;
+DROP TABLE access_lists;
+DROP TABLE transactions;
+DROP TABLE blocks;
@@ -0,0 +1,221 @@
+-- blocks table
+CREATE TABLE blocks (
+	block_number BIGINT PRIMARY KEY,
+	block_hash VARCHAR(66) NOT NULL,
+	parent_hash VARCHAR(66) NOT NULL,
+	miner VARCHAR(42) NOT NULL,
+	mined_timestamp TIMESTAMPTZ NOT NULL,
+	gas_limit BIGINT NOT NULL,
+	gas_used BIGINT NOT NULL,
+	base_fee_per_gas NUMERIC(78,0), -- wei, NULL before London
+	difficulty NUMERIC(78,0) NOT NULL,
+	total_difficulty NUMERIC(78,0), -- NULL when the parent was not indexed first
+	extra_data TEXT NOT NULL,
+	size BIGINT NOT NULL,
+	state_root VARCHAR(66) NOT NULL,
+	withdrawals_root VARCHAR(66), -- NULL before Shanghai
+	tx_count INT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+CREATE INDEX blocks_timestamp_idx ON blocks (mined_timestamp);
+
+-- transactions table
+CREATE TABLE transactions (
+	tx_hash VARCHAR(66) NOT NULL PRIMARY KEY,
+	block_number BIGINT NOT NULL REFERENCES blocks (block_number) ON DELETE CASCADE,
+	tx_from VARCHAR(42) NOT NULL,
+	tx_to VARCHAR(42), -- NULL on contract creation
+	amount NUMERIC(78,0) NOT NULL, -- wei
+	nonce BIGINT NOT NULL,
+	mined_timestamp TIMESTAMPTZ NOT NULL,
+	tx_order INT NOT NULL,
+	tx_type SMALLINT NOT NULL,
+	gas_limit BIGINT NOT NULL,
+	gas_price NUMERIC(78,0) NOT NULL,
+	max_fee_per_gas NUMERIC(78,0), -- NULL before EIP-1559
+	max_priority_fee_per_gas NUMERIC(78,0), -- NULL before EIP-1559
+	max_fee_per_blob_gas NUMERIC(78,0), -- NULL except blob transactions
+	input TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+CREATE INDEX transactions_block_idx ON transactions (block_number, tx_order);
+CREATE INDEX transactions_from_idx ON transactions (tx_from, block_number, tx_order);
+CREATE INDEX transactions_to_idx ON transactions (tx_to, block_number, tx_order);
+
+-- access lists table
+CREATE TABLE access_lists (
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	entry_index INT NOT NULL,
+	address VARCHAR(42) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, entry_index)
+);
+
+-- access list storage keys table
+CREATE TABLE access_list_keys (
+	tx_hash VARCHAR(66) NOT NULL,
+	entry_index INT NOT NULL,
+	key_index INT NOT NULL,
+	storage_key VARCHAR(66) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, entry_index, key_index),
+	FOREIGN KEY (tx_hash, entry_index) REFERENCES access_lists (tx_hash, entry_index) ON DELETE CASCADE
+);
+
+-- blob versioned hashes table
+CREATE TABLE blob_hashes (
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	blob_index INT NOT NULL,
+	versioned_hash VARCHAR(66) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, blob_index)
+);
+
+-- internal transactions table
+CREATE TABLE internal_txs (
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	trace_index INT NOT NULL,
+	block_number BIGINT NOT NULL,
+	depth INT NOT NULL,
+	call_type TEXT NOT NULL,
+	tx_from VARCHAR(42) NOT NULL,
+	tx_to VARCHAR(42) NOT NULL,
+	amount NUMERIC(78,0) NOT NULL, -- wei
+	error TEXT,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, trace_index)
+);
+
+CREATE INDEX internal_txs_from_idx ON internal_txs (tx_from, block_number);
+CREATE INDEX internal_txs_to_idx ON internal_txs (tx_to, block_number);
+
+-- receipts table
+CREATE TABLE receipts (
+	tx_hash VARCHAR(66) NOT NULL PRIMARY KEY REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	block_number BIGINT NOT NULL,
+	status SMALLINT NOT NULL,
+	gas_used BIGINT NOT NULL,
+	cumulative_gas_used BIGINT NOT NULL,
+	effective_gas_price NUMERIC(78,0) NOT NULL,
+	contract_address VARCHAR(42),
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- logs table
+CREATE TABLE logs (
+	block_number BIGINT NOT NULL,
+	log_index INT NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	address VARCHAR(42) NOT NULL,
+	topic0 VARCHAR(66),
+	topic1 VARCHAR(66),
+	topic2 VARCHAR(66),
+	topic3 VARCHAR(66),
+	data TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index)
+);
+
+CREATE INDEX logs_tx_hash_idx ON logs (tx_hash);
+CREATE INDEX logs_address_idx ON logs (address, block_number);
+CREATE INDEX logs_topic0_idx ON logs (topic0, block_number);
+CREATE INDEX logs_topic1_idx ON logs (topic1, block_number);
+CREATE INDEX logs_topic2_idx ON logs (topic2, block_number);
+CREATE INDEX logs_topic3_idx ON logs (topic3, block_number);
+
+-- tokens table
+CREATE TABLE tokens (
+	address VARCHAR(42) NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	decimals SMALLINT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+-- token transfers table
+CREATE TABLE token_transfers (
+	block_number BIGINT NOT NULL,
+	log_index INT NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	token VARCHAR(42) NOT NULL,
+	transfer_from VARCHAR(42) NOT NULL,
+	transfer_to VARCHAR(42) NOT NULL,
+	amount NUMERIC(78,0) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index)
+);
+
+CREATE INDEX token_transfers_token_idx ON token_transfers (token, block_number);
+CREATE INDEX token_transfers_from_idx ON token_transfers (transfer_from, block_number);
+CREATE INDEX token_transfers_to_idx ON token_transfers (transfer_to, block_number);
+
+-- nft transfers table
+CREATE TABLE nft_transfers (
+	block_number BIGINT NOT NULL,
+	log_index INT NOT NULL,
+	batch_index INT NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	standard TEXT NOT NULL,
+	collection VARCHAR(42) NOT NULL,
+	operator VARCHAR(42) NOT NULL,
+	transfer_from VARCHAR(42) NOT NULL,
+	transfer_to VARCHAR(42) NOT NULL,
+	token_id NUMERIC(78,0) NOT NULL,
+	quantity NUMERIC(78,0) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index, batch_index)
+);
+
+CREATE INDEX nft_transfers_token_idx ON nft_transfers (collection, token_id, block_number);
+CREATE INDEX nft_transfers_from_idx ON nft_transfers (transfer_from, block_number);
+CREATE INDEX nft_transfers_to_idx ON nft_transfers (transfer_to, block_number);
+
+-- withdrawals table
+CREATE TABLE withdrawals (
+	withdrawal_index BIGINT PRIMARY KEY,
+	block_number BIGINT NOT NULL REFERENCES blocks (block_number) ON DELETE CASCADE,
+	validator_index BIGINT NOT NULL,
+	address VARCHAR(42) NOT NULL,
+	amount BIGINT NOT NULL, -- gwei
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+CREATE INDEX withdrawals_block_idx ON withdrawals (block_number);
+CREATE INDEX withdrawals_address_idx ON withdrawals (address, block_number);
+
+-- contracts table
+CREATE TABLE contracts (
+	address VARCHAR(42) NOT NULL PRIMARY KEY,
+	deployer VARCHAR(42) NOT NULL,
+	tx_hash VARCHAR(66) NOT NULL REFERENCES transactions (tx_hash) ON DELETE CASCADE,
+	block_number BIGINT NOT NULL,
+	code_hash VARCHAR(66), -- NULL when the source has no state
+	created_at TIMESTAMPTZ DEFAULT current_timestamp
+);
+
+CREATE INDEX contracts_deployer_idx ON contracts (deployer, block_number);
+
+-- balance deltas table
+CREATE TABLE balance_deltas (
+	block_number BIGINT NOT NULL REFERENCES blocks (block_number) ON DELETE CASCADE,
+	address VARCHAR(42) NOT NULL,
+	delta NUMERIC(78,0) NOT NULL, -- wei, signed
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, address)
+);
+
+CREATE INDEX balance_deltas_address_idx ON balance_deltas (address, block_number);
+
+-- scan ranges table
+CREATE TABLE scan_ranges (
+	id BIGSERIAL PRIMARY KEY,
+	start_block BIGINT NOT NULL,
+	end_block BIGINT NOT NULL,
+	cursor BIGINT NOT NULL,
+	follow BOOLEAN NOT NULL DEFAULT FALSE,
+	done BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT current_timestamp,
+	updated_at TIMESTAMPTZ DEFAULT current_timestamp
+);
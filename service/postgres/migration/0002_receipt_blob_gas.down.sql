@@ -0,0 +1,2 @@
+ALTER TABLE receipts DROP COLUMN blob_gas_price;
+ALTER TABLE receipts DROP COLUMN blob_gas_used;
@@ -0,0 +1,3 @@
+-- blob gas of EIP-4844 receipts, burned on top of the gas fee
+ALTER TABLE receipts ADD COLUMN blob_gas_used BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE receipts ADD COLUMN blob_gas_price NUMERIC(78,0); -- NULL except blob transactions
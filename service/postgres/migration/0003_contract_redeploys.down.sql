@@ -0,0 +1,4 @@
+-- the latest deployment of each address only
+DELETE FROM contracts c1 USING contracts c2 WHERE c1.address = c2.address AND c1.block_number < c2.block_number;
+ALTER TABLE contracts DROP CONSTRAINT contracts_pkey;
+ALTER TABLE contracts ADD PRIMARY KEY (address);
@@ -0,0 +1,4 @@
+-- an address is deployed again after a self-destruct (e.g. CREATE2),
+-- keep every deployment rather than the first one.
+ALTER TABLE contracts DROP CONSTRAINT contracts_pkey;
+ALTER TABLE contracts ADD PRIMARY KEY (address, tx_hash);
@@ -0,0 +1,2 @@
+-- state root of pre-Byzantium receipts, which have no status
+ALTER TABLE receipts ADD COLUMN post_state VARCHAR(66); -- NULL after Byzantium
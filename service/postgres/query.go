@@ -0,0 +1,728 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/twiny/blockscan/service/sqlstore"
+)
+
+// queries of the store in the `$n` placeholder syntax.
+var queries = &sqlstore.Queries{
+	Placeholder: func(n int) string {
+		return fmt.Sprintf("$%d", n)
+	},
+	SelectLatestBlock:           selectLatestBlock,
+	SelectBlock:                 selectBlock,
+	SelectAllTxsHashsByBlockID:  selectAllTxsHashsByBlockID,
+	SelectWithdrawalsByBlockID:  selectWithdrawalsByBlockID,
+	SelectAddressWithdrawals:    selectAddressWithdrawals,
+	SelectLatestTx:              selectLatestTx,
+	SelectTx:                    selectTx,
+	SelectAddressTxs:            selectAddressTxs,
+	SelectAccessList:            selectAccessList,
+	SelectAccessListKeys:        selectAccessListKeys,
+	SelectBlobHashes:            selectBlobHashes,
+	SelectInternalTxs:           selectInternalTxs,
+	SelectAddressInternalTxs:    selectAddressInternalTxs,
+	SelectReceipt:               selectReceipt,
+	SelectLogsByTx:              selectLogsByTx,
+	SelectLogs:                  selectLogs,
+	SelectToken:                 selectToken,
+	SelectTokenTransfers:        selectTokenTransfers,
+	SelectAddressTokenTransfers: selectAddressTokenTransfers,
+	SelectNFTTransfers:          selectNFTTransfers,
+	SelectNFTTokenTransfers:     selectNFTTokenTransfers,
+	SelectBalance:               selectBalance,
+	SelectBalanceAddresses:      selectBalanceAddresses,
+	SelectContract:              selectContract,
+	SelectDeployerContracts:     selectDeployerContracts,
+	SelectTxHashes:              selectTxHashes,
+	SelectTxAmounts:             selectTxAmounts,
+	SelectGaps:                  selectGaps,
+	HasScanned:                  hasScanned,
+	SelectBlockHash:             selectBlockHash,
+	SelectTotalDifficulty:       selectTotalDifficulty,
+	UpdateTotalDifficulty:       updateTotalDifficulty,
+	DeleteBlocks:                deleteBlocks,
+	InsertBlock:                 insertBlock,
+	InsertWithdrawal:            insertWithdrawal,
+	InsertTx:                    insertTx,
+	InsertAccessList:            insertAccessList,
+	InsertAccessListKey:         insertAccessListKey,
+	InsertBlobHash:              insertBlobHash,
+	SelectScanRanges:            selectScanRanges,
+	InsertScanRange:             insertScanRange,
+	UpdateScanRange:             updateScanRange,
+	HasToken:                    hasToken,
+	InsertToken:                 insertToken,
+	InsertTokenTransfer:         insertTokenTransfer,
+	InsertNFTTransfer:           insertNFTTransfer,
+	InsertInternalTx:            insertInternalTx,
+	InsertReceipt:               insertReceipt,
+	InsertLog:                   insertLog,
+	InsertBalanceDelta:          insertBalanceDelta,
+	InsertContract:              insertContract,
+}
+
+// // Rest \\ \\
+const selectLatestBlock = `
+SELECT
+	b1.block_number,
+	b1.block_hash,
+	b1.parent_hash,
+	b1.miner,
+	b1.mined_timestamp,
+	b1.gas_limit,
+	b1.gas_used,
+	COALESCE(b1.base_fee_per_gas::TEXT, ''),
+	b1.difficulty::TEXT,
+	COALESCE(b1.total_difficulty::TEXT, ''),
+	b1.extra_data,
+	b1.size,
+	b1.state_root,
+	COALESCE(b1.withdrawals_root, ''),
+	b1.tx_count
+FROM
+	blocks b1
+ORDER BY
+	b1.mined_timestamp DESC
+LIMIT 1
+`
+
+const selectBlock = `
+SELECT
+	b1.block_number,
+	b1.block_hash,
+	b1.parent_hash,
+	b1.miner,
+	b1.mined_timestamp,
+	b1.gas_limit,
+	b1.gas_used,
+	COALESCE(b1.base_fee_per_gas::TEXT, ''),
+	b1.difficulty::TEXT,
+	COALESCE(b1.total_difficulty::TEXT, ''),
+	b1.extra_data,
+	b1.size,
+	b1.state_root,
+	COALESCE(b1.withdrawals_root, ''),
+	b1.tx_count
+FROM
+	blocks b1
+WHERE
+	b1.block_number = $1
+`
+
+const selectAllTxsHashsByBlockID = `
+SELECT
+	t1.tx_hash
+FROM
+	transactions t1
+WHERE
+	t1.block_number = $1
+`
+
+const selectWithdrawalsByBlockID = `
+SELECT
+	w1.withdrawal_index,
+	w1.block_number,
+	w1.validator_index,
+	w1.address,
+	w1.amount
+FROM
+	withdrawals w1
+WHERE
+	w1.block_number = $1
+ORDER BY
+	w1.withdrawal_index ASC
+`
+
+const selectAddressWithdrawals = `
+SELECT
+	w1.withdrawal_index,
+	w1.block_number,
+	w1.validator_index,
+	w1.address,
+	w1.amount
+FROM
+	withdrawals w1
+WHERE
+	w1.address = $1
+	AND (w1.block_number BETWEEN $2 AND $3)
+ORDER BY
+	w1.withdrawal_index DESC
+LIMIT $4
+`
+
+const selectLatestTx = `
+SELECT
+	t1.tx_hash,
+	t1.block_number,
+	t1.tx_from,
+	COALESCE(t1.tx_to, ''),
+	t1.amount::TEXT,
+	t1.nonce,
+	t1.mined_timestamp,
+	t1.tx_order,
+	t1.tx_type,
+	t1.gas_limit,
+	t1.gas_price::TEXT,
+	COALESCE(t1.max_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_priority_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_fee_per_blob_gas::TEXT, ''),
+	t1.input
+FROM
+	transactions t1
+WHERE
+	t1.block_number = (
+		SELECT
+			b1.block_number
+		FROM
+			blocks b1
+		ORDER BY
+			b1.mined_timestamp DESC
+		LIMIT 1)
+ORDER BY
+	t1.tx_order DESC
+LIMIT 1
+`
+
+const selectTx = `
+SELECT
+	t1.tx_hash,
+	t1.block_number,
+	t1.tx_from,
+	COALESCE(t1.tx_to, ''),
+	t1.amount::TEXT,
+	t1.nonce,
+	t1.mined_timestamp,
+	t1.tx_order,
+	t1.tx_type,
+	t1.gas_limit,
+	t1.gas_price::TEXT,
+	COALESCE(t1.max_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_priority_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_fee_per_blob_gas::TEXT, ''),
+	t1.input
+FROM
+	transactions t1
+WHERE
+	t1.tx_hash = $1
+`
+
+// selectAddressTxs - filters are appended by `GetAddressTxs`.
+const selectAddressTxs = `
+SELECT
+	t1.tx_hash,
+	t1.block_number,
+	t1.tx_from,
+	COALESCE(t1.tx_to, ''),
+	t1.amount::TEXT,
+	t1.nonce,
+	t1.mined_timestamp,
+	t1.tx_order,
+	t1.tx_type,
+	t1.gas_limit,
+	t1.gas_price::TEXT,
+	COALESCE(t1.max_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_priority_fee_per_gas::TEXT, ''),
+	COALESCE(t1.max_fee_per_blob_gas::TEXT, ''),
+	t1.input
+FROM
+	transactions t1
+WHERE (t1.block_number BETWEEN $1 AND $2)
+`
+
+const selectAccessList = `
+SELECT
+	a1.entry_index,
+	a1.address
+FROM
+	access_lists a1
+WHERE
+	a1.tx_hash = $1
+ORDER BY
+	a1.entry_index ASC
+`
+
+const selectAccessListKeys = `
+SELECT
+	k1.entry_index,
+	k1.storage_key
+FROM
+	access_list_keys k1
+WHERE
+	k1.tx_hash = $1
+ORDER BY
+	k1.entry_index ASC,
+	k1.key_index ASC
+`
+
+const selectBlobHashes = `
+SELECT
+	b1.versioned_hash
+FROM
+	blob_hashes b1
+WHERE
+	b1.tx_hash = $1
+ORDER BY
+	b1.blob_index ASC
+`
+
+const selectInternalTxs = `
+SELECT
+	i1.tx_hash,
+	i1.block_number,
+	i1.trace_index,
+	i1.depth,
+	i1.call_type,
+	i1.tx_from,
+	i1.tx_to,
+	i1.amount::TEXT,
+	COALESCE(i1.error, '')
+FROM
+	internal_txs i1
+WHERE
+	i1.tx_hash = $1
+ORDER BY
+	i1.trace_index ASC
+`
+
+const selectAddressInternalTxs = `
+SELECT
+	i1.tx_hash,
+	i1.block_number,
+	i1.trace_index,
+	i1.depth,
+	i1.call_type,
+	i1.tx_from,
+	i1.tx_to,
+	i1.amount::TEXT,
+	COALESCE(i1.error, '')
+FROM
+	internal_txs i1
+WHERE (i1.tx_from = $1 OR i1.tx_to = $2)
+	AND (i1.block_number BETWEEN $3 AND $4)
+ORDER BY
+	i1.block_number DESC,
+	i1.tx_hash DESC,
+	i1.trace_index DESC
+LIMIT $5
+`
+
+const selectReceipt = `
+SELECT
+	r1.tx_hash,
+	r1.block_number,
+	r1.status,
+	COALESCE(r1.post_state, ''),
+	r1.gas_used,
+	r1.cumulative_gas_used,
+	r1.effective_gas_price::TEXT,
+	COALESCE(r1.contract_address, ''),
+	r1.blob_gas_used,
+	COALESCE(r1.blob_gas_price::TEXT, '')
+FROM
+	receipts r1
+WHERE
+	r1.tx_hash = $1
+`
+
+const selectLogsByTx = `
+SELECT
+	l1.tx_hash,
+	l1.block_number,
+	l1.log_index,
+	l1.address,
+	l1.topic0,
+	l1.topic1,
+	l1.topic2,
+	l1.topic3,
+	l1.data
+FROM
+	logs l1
+WHERE
+	l1.tx_hash = $1
+ORDER BY
+	l1.log_index ASC
+`
+
+// selectLogs - filters are appended by `GetLogs`.
+const selectLogs = `
+SELECT
+	l1.tx_hash,
+	l1.block_number,
+	l1.log_index,
+	l1.address,
+	l1.topic0,
+	l1.topic1,
+	l1.topic2,
+	l1.topic3,
+	l1.data
+FROM
+	logs l1
+WHERE (l1.block_number BETWEEN $1 AND $2)
+`
+
+const selectToken = `
+SELECT
+	t1.address,
+	t1.name,
+	t1.symbol,
+	t1.decimals
+FROM
+	tokens t1
+WHERE
+	t1.address = $1
+`
+
+const selectTokenTransfers = `
+SELECT
+	t1.tx_hash,
+	t1.block_number,
+	t1.log_index,
+	t1.token,
+	t1.transfer_from,
+	t1.transfer_to,
+	t1.amount::TEXT
+FROM
+	token_transfers t1
+WHERE
+	t1.token = $1
+	AND (t1.block_number BETWEEN $2 AND $3)
+ORDER BY
+	t1.block_number DESC,
+	t1.log_index DESC
+LIMIT $4
+`
+
+const selectAddressTokenTransfers = `
+SELECT
+	t1.tx_hash,
+	t1.block_number,
+	t1.log_index,
+	t1.token,
+	t1.transfer_from,
+	t1.transfer_to,
+	t1.amount::TEXT
+FROM
+	token_transfers t1
+WHERE (t1.transfer_from = $1 OR t1.transfer_to = $2)
+	AND (t1.block_number BETWEEN $3 AND $4)
+ORDER BY
+	t1.block_number DESC,
+	t1.log_index DESC
+LIMIT $5
+`
+
+// selectNFTTransfers - filters are appended by `GetNFTTransfers`.
+const selectNFTTransfers = `
+SELECT
+	n1.tx_hash,
+	n1.block_number,
+	n1.log_index,
+	n1.batch_index,
+	n1.standard,
+	n1.collection,
+	n1.operator,
+	n1.transfer_from,
+	n1.transfer_to,
+	n1.token_id::TEXT,
+	n1.quantity::TEXT
+FROM
+	nft_transfers n1
+WHERE (n1.block_number BETWEEN $1 AND $2)
+`
+
+const selectNFTTokenTransfers = `
+SELECT
+	n1.transfer_from,
+	n1.transfer_to,
+	n1.quantity::TEXT
+FROM
+	nft_transfers n1
+WHERE
+	n1.collection = $1
+	AND n1.token_id = $2
+ORDER BY
+	n1.block_number ASC,
+	n1.log_index ASC,
+	n1.batch_index ASC
+`
+
+const selectBalance = `
+SELECT
+	COALESCE(SUM(d1.delta), 0)::TEXT
+FROM
+	balance_deltas d1
+WHERE
+	d1.address = $1
+	AND d1.block_number <= $2
+`
+
+const selectBalanceAddresses = `
+SELECT
+	d1.address
+FROM (
+	SELECT DISTINCT
+		d2.address
+	FROM
+		balance_deltas d2
+	WHERE (d2.block_number BETWEEN $1 AND $2)
+) d1
+ORDER BY
+	RANDOM()
+LIMIT $3
+`
+
+const selectContract = `
+SELECT
+	c1.address,
+	c1.deployer,
+	c1.tx_hash,
+	c1.block_number,
+	COALESCE(c1.code_hash, '')
+FROM
+	contracts c1
+WHERE
+	c1.address = $1
+ORDER BY
+	c1.block_number DESC
+LIMIT 1
+`
+
+const selectDeployerContracts = `
+SELECT
+	c1.address,
+	c1.deployer,
+	c1.tx_hash,
+	c1.block_number,
+	COALESCE(c1.code_hash, '')
+FROM
+	contracts c1
+WHERE
+	c1.deployer = $1
+	AND (c1.block_number BETWEEN $2 AND $3)
+ORDER BY
+	c1.block_number DESC
+LIMIT $4
+`
+
+const selectTxHashes = `
+SELECT
+	t1.tx_hash
+FROM
+	transactions t1
+	WHERE (t1.block_number BETWEEN $1 AND $2)
+`
+
+const selectTxAmounts = `
+SELECT
+	COALESCE(SUM(t1.amount), 0)::TEXT
+FROM
+	transactions t1
+	WHERE (t1.block_number BETWEEN $1 AND $2)
+`
+
+// selectGaps - the first row is a sentinel block
+// before the range to detect a leading gap.
+const selectGaps = `
+WITH b1 AS (
+	SELECT
+		$1::BIGINT - 1 AS block_number
+	UNION ALL
+	SELECT
+		b2.block_number
+	FROM
+		blocks b2
+	WHERE (b2.block_number BETWEEN $2 AND $3)
+)
+SELECT
+	g1.gap_start,
+	g1.gap_end
+FROM (
+	SELECT
+		b1.block_number + 1 AS gap_start,
+		LEAD(b1.block_number, 1, $4::BIGINT + 1) OVER (ORDER BY b1.block_number) - 1 AS gap_end
+	FROM
+		b1
+) g1
+WHERE
+	g1.gap_end >= g1.gap_start
+ORDER BY
+	g1.gap_start ASC
+`
+
+// // Indexer \\ \\
+
+const hasScanned = `
+SELECT EXISTS(SELECT 1 FROM blocks b1 WHERE b1.block_number = $1) AS found;
+`
+
+const selectBlockHash = `
+SELECT
+	b1.block_hash
+FROM
+	blocks b1
+WHERE
+	b1.block_number = $1
+`
+
+const selectTotalDifficulty = `
+SELECT
+	COALESCE(b1.total_difficulty::TEXT, '')
+FROM
+	blocks b1
+WHERE
+	b1.block_number = $1
+`
+
+const updateTotalDifficulty = `
+UPDATE "blocks"
+SET
+	total_difficulty = $1
+WHERE
+	block_number = $2;
+`
+
+const deleteBlocks = `
+DELETE FROM "blocks"
+WHERE
+	block_number BETWEEN $1 AND $2;
+`
+
+const insertBlock = `
+INSERT INTO "blocks"
+	(block_number, block_hash, parent_hash, miner, mined_timestamp, gas_limit, gas_used, base_fee_per_gas, difficulty, total_difficulty, extra_data, size, state_root, withdrawals_root, tx_count)
+VALUES 
+	($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15);
+`
+
+const insertWithdrawal = `
+INSERT INTO "withdrawals"
+	(withdrawal_index, block_number, validator_index, address, amount)
+VALUES
+	($1,$2,$3,$4,$5);
+`
+
+const insertTx = `
+INSERT INTO "transactions"
+	(tx_hash, block_number, tx_from, tx_to, amount, nonce, mined_timestamp, tx_order, tx_type, gas_limit, gas_price, max_fee_per_gas, max_priority_fee_per_gas, max_fee_per_blob_gas, input)
+VALUES 
+	($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15);
+`
+
+const insertAccessList = `
+INSERT INTO "access_lists"
+	(tx_hash, entry_index, address)
+VALUES
+	($1,$2,$3);
+`
+
+const insertAccessListKey = `
+INSERT INTO "access_list_keys"
+	(tx_hash, entry_index, key_index, storage_key)
+VALUES
+	($1,$2,$3,$4);
+`
+
+const insertBlobHash = `
+INSERT INTO "blob_hashes"
+	(tx_hash, blob_index, versioned_hash)
+VALUES
+	($1,$2,$3);
+`
+
+const selectScanRanges = `
+SELECT
+	s1.id,
+	s1.start_block,
+	s1.end_block,
+	s1.cursor,
+	s1.follow,
+	s1.done
+FROM
+	scan_ranges s1
+ORDER BY
+	s1.id ASC
+`
+
+const insertScanRange = `
+INSERT INTO "scan_ranges"
+	(start_block, end_block, cursor, follow, done)
+VALUES
+	($1,$2,$3,$4,$5)
+RETURNING
+	id;
+`
+
+const updateScanRange = `
+UPDATE "scan_ranges"
+SET
+	end_block = $1,
+	cursor = $2,
+	done = $3,
+	updated_at = current_timestamp
+WHERE
+	id = $4;
+`
+
+const hasToken = `
+SELECT EXISTS(SELECT 1 FROM tokens t1 WHERE t1.address = $1) AS found;
+`
+
+const insertToken = `
+INSERT INTO "tokens"
+	(address, name, symbol, decimals)
+VALUES
+	($1,$2,$3,$4)
+ON CONFLICT (address) DO NOTHING;
+`
+
+const insertTokenTransfer = `
+INSERT INTO "token_transfers"
+	(block_number, log_index, tx_hash, token, transfer_from, transfer_to, amount)
+VALUES
+	($1,$2,$3,$4,$5,$6,$7);
+`
+
+const insertNFTTransfer = `
+INSERT INTO "nft_transfers"
+	(block_number, log_index, batch_index, tx_hash, standard, collection, operator, transfer_from, transfer_to, token_id, quantity)
+VALUES
+	($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11);
+`
+
+const insertInternalTx = `
+INSERT INTO "internal_txs"
+	(tx_hash, trace_index, block_number, depth, call_type, tx_from, tx_to, amount, error)
+VALUES
+	($1,$2,$3,$4,$5,$6,$7,$8,$9);
+`
+
+const insertReceipt = `
+INSERT INTO "receipts"
+	(tx_hash, block_number, status, post_state, gas_used, cumulative_gas_used, effective_gas_price, contract_address, blob_gas_used, blob_gas_price)
+VALUES
+	($1,$2,$3,$4,$5,$6,$7,$8,$9,$10);
+`
+
+const insertLog = `
+INSERT INTO "logs"
+	(block_number, log_index, tx_hash, address, topic0, topic1, topic2, topic3, data)
+VALUES
+	($1,$2,$3,$4,$5,$6,$7,$8,$9);
+`
+
+const insertBalanceDelta = `
+INSERT INTO "balance_deltas"
+	(block_number, address, delta)
+VALUES
+	($1,$2,$3);
+`
+
+const insertContract = `
+INSERT INTO "contracts"
+	(address, deployer, tx_hash, block_number, code_hash)
+VALUES
+	($1,$2,$3,$4,$5);
+`
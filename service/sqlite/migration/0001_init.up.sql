@@ -1,5 +1,5 @@
 -- blocks table
-CREATE TABLE IF NOT EXISTS blocks (
+CREATE TABLE blocks (
 	block_number INT PRIMARY KEY,
 	block_hash CHAR(32) NOT NULL,
 	mined_timestamp TIMESTAMP NOT NULL,
@@ -8,7 +8,7 @@ CREATE TABLE IF NOT EXISTS blocks (
 );
 
 -- transactions table
-CREATE TABLE IF NOT EXISTS transactions (
+CREATE TABLE transactions (
 	tx_hash CHAR(32) NOT NULL PRIMARY KEY,
 	block_number INT NOT NULL,
 	tx_from CHAR(32) NOT NULL,
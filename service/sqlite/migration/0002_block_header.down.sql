@@ -0,0 +1,11 @@
+ALTER TABLE blocks DROP COLUMN withdrawals_root;
+ALTER TABLE blocks DROP COLUMN state_root;
+ALTER TABLE blocks DROP COLUMN size;
+ALTER TABLE blocks DROP COLUMN extra_data;
+ALTER TABLE blocks DROP COLUMN total_difficulty;
+ALTER TABLE blocks DROP COLUMN difficulty;
+ALTER TABLE blocks DROP COLUMN base_fee_per_gas;
+ALTER TABLE blocks DROP COLUMN gas_used;
+ALTER TABLE blocks DROP COLUMN gas_limit;
+ALTER TABLE blocks DROP COLUMN miner;
+ALTER TABLE blocks DROP COLUMN parent_hash;
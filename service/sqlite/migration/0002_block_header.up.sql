@@ -0,0 +1,12 @@
+-- full header fields, blocks indexed before keep the defaults
+ALTER TABLE blocks ADD COLUMN parent_hash CHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE blocks ADD COLUMN miner CHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE blocks ADD COLUMN gas_limit INT NOT NULL DEFAULT 0;
+ALTER TABLE blocks ADD COLUMN gas_used INT NOT NULL DEFAULT 0;
+ALTER TABLE blocks ADD COLUMN base_fee_per_gas TEXT; -- wei, NULL before London
+ALTER TABLE blocks ADD COLUMN difficulty TEXT NOT NULL DEFAULT '0';
+ALTER TABLE blocks ADD COLUMN total_difficulty TEXT; -- NULL when the parent was not indexed first
+ALTER TABLE blocks ADD COLUMN extra_data TEXT NOT NULL DEFAULT '0x';
+ALTER TABLE blocks ADD COLUMN size INT NOT NULL DEFAULT 0;
+ALTER TABLE blocks ADD COLUMN state_root CHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE blocks ADD COLUMN withdrawals_root CHAR(32); -- NULL before Shanghai
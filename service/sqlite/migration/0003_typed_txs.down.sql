@@ -0,0 +1,23 @@
+DROP TABLE blob_hashes;
+DROP TABLE access_list_keys;
+DROP TABLE access_lists;
+
+CREATE TABLE transactions_untyped (
+	tx_hash CHAR(32) NOT NULL PRIMARY KEY,
+	block_number INT NOT NULL,
+	tx_from CHAR(32) NOT NULL,
+	tx_to CHAR(32) NOT NULL,
+	amount NUMERIC NOT NULL,
+	nonce INT NOT NULL,
+	mined_timestamp TIMESTAMP NOT NULL,
+	tx_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (block_number) REFERENCES blocks (block_number) ON DELETE CASCADE
+);
+
+INSERT INTO transactions_untyped
+SELECT tx_hash, block_number, tx_from, COALESCE(tx_to, ''), amount, nonce, mined_timestamp, tx_order, created_at
+FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_untyped RENAME TO transactions;
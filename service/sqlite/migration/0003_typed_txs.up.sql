@@ -0,0 +1,78 @@
+-- amounts as lossless wei strings, a NULL recipient on contract creation
+-- & typed transaction fields. SQLite can't alter a column, the table is
+-- rebuilt before any other table references it. NUMERIC amounts over 64
+-- bits were stored as reals, they are written back in full but their
+-- precision is lost already.
+CREATE TABLE transactions_typed (
+	tx_hash CHAR(32) NOT NULL PRIMARY KEY,
+	block_number INT NOT NULL,
+	tx_from CHAR(32) NOT NULL,
+	tx_to CHAR(32), -- NULL on contract creation
+	amount TEXT NOT NULL, -- wei, base 10
+	nonce INT NOT NULL,
+	mined_timestamp TIMESTAMP NOT NULL,
+	tx_order INT NOT NULL,
+	tx_type INT NOT NULL,
+	gas_limit INT NOT NULL,
+	gas_price TEXT NOT NULL,
+	max_fee_per_gas TEXT, -- NULL before EIP-1559
+	max_priority_fee_per_gas TEXT, -- NULL before EIP-1559
+	max_fee_per_blob_gas TEXT, -- NULL except blob transactions
+	input TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (block_number) REFERENCES blocks (block_number) ON DELETE CASCADE
+);
+
+INSERT INTO transactions_typed (tx_hash, block_number, tx_from, tx_to, amount, nonce, mined_timestamp, tx_order, tx_type, gas_limit, gas_price, input, created_at)
+SELECT
+	tx_hash,
+	block_number,
+	tx_from,
+	NULLIF(tx_to, ''),
+	CASE typeof(amount) WHEN 'real' THEN printf('%.0f', amount) ELSE CAST(amount AS TEXT) END,
+	nonce,
+	mined_timestamp,
+	tx_order,
+	0,
+	0,
+	'0',
+	'0x',
+	created_at
+FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_typed RENAME TO transactions;
+
+CREATE INDEX transactions_from_idx ON transactions (tx_from, block_number, tx_order);
+CREATE INDEX transactions_to_idx ON transactions (tx_to, block_number, tx_order);
+
+-- access lists table
+CREATE TABLE access_lists (
+	tx_hash CHAR(32) NOT NULL,
+	entry_index INT NOT NULL,
+	address CHAR(20) NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, entry_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+-- access list storage keys table
+CREATE TABLE access_list_keys (
+	tx_hash CHAR(32) NOT NULL,
+	entry_index INT NOT NULL,
+	key_index INT NOT NULL,
+	storage_key CHAR(32) NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, entry_index, key_index),
+	FOREIGN KEY (tx_hash, entry_index) REFERENCES access_lists (tx_hash, entry_index) ON DELETE CASCADE
+);
+
+-- blob versioned hashes table
+CREATE TABLE blob_hashes (
+	tx_hash CHAR(32) NOT NULL,
+	blob_index INT NOT NULL,
+	versioned_hash CHAR(32) NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, blob_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+-- scan ranges table
+CREATE TABLE scan_ranges (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	start_block INT NOT NULL,
+	end_block INT NOT NULL,
+	cursor INT NOT NULL,
+	follow BOOLEAN NOT NULL DEFAULT 0,
+	done BOOLEAN NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	updated_at TIMESTAMP DEFAULT current_timestamp
+);
@@ -0,0 +1,37 @@
+-- receipts table
+CREATE TABLE receipts (
+	tx_hash CHAR(32) NOT NULL PRIMARY KEY,
+	block_number INT NOT NULL,
+	status INT NOT NULL,
+	gas_used INT NOT NULL,
+	cumulative_gas_used INT NOT NULL,
+	effective_gas_price TEXT NOT NULL,
+	contract_address CHAR(20),
+	blob_gas_used INT NOT NULL DEFAULT 0,
+	blob_gas_price TEXT, -- NULL except blob transactions
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+-- logs table
+CREATE TABLE logs (
+	block_number INT NOT NULL,
+	log_index INT NOT NULL,
+	tx_hash CHAR(32) NOT NULL,
+	address CHAR(20) NOT NULL,
+	topic0 CHAR(32),
+	topic1 CHAR(32),
+	topic2 CHAR(32),
+	topic3 CHAR(32),
+	data TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+CREATE INDEX logs_tx_hash_idx ON logs (tx_hash);
+CREATE INDEX logs_address_idx ON logs (address, block_number);
+CREATE INDEX logs_topic0_idx ON logs (topic0, block_number);
+CREATE INDEX logs_topic1_idx ON logs (topic1, block_number);
+CREATE INDEX logs_topic2_idx ON logs (topic2, block_number);
+CREATE INDEX logs_topic3_idx ON logs (topic3, block_number);
@@ -0,0 +1,2 @@
+DROP TABLE token_transfers;
+DROP TABLE tokens;
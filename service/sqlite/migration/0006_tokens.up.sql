@@ -0,0 +1,26 @@
+-- tokens table
+CREATE TABLE tokens (
+	address CHAR(20) NOT NULL PRIMARY KEY,
+	name TEXT NOT NULL,
+	symbol TEXT NOT NULL,
+	decimals INT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+-- token transfers table
+CREATE TABLE token_transfers (
+	block_number INT NOT NULL,
+	log_index INT NOT NULL,
+	tx_hash CHAR(32) NOT NULL,
+	token CHAR(20) NOT NULL,
+	transfer_from CHAR(20) NOT NULL,
+	transfer_to CHAR(20) NOT NULL,
+	amount TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+CREATE INDEX token_transfers_token_idx ON token_transfers (token, block_number);
+CREATE INDEX token_transfers_from_idx ON token_transfers (transfer_from, block_number);
+CREATE INDEX token_transfers_to_idx ON token_transfers (transfer_to, block_number);
@@ -0,0 +1 @@
+DROP TABLE nft_transfers;
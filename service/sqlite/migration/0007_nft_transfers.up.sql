@@ -0,0 +1,21 @@
+-- nft transfers table
+CREATE TABLE nft_transfers (
+	block_number INT NOT NULL,
+	log_index INT NOT NULL,
+	batch_index INT NOT NULL,
+	tx_hash CHAR(32) NOT NULL,
+	standard TEXT NOT NULL,
+	collection CHAR(20) NOT NULL,
+	operator CHAR(20) NOT NULL,
+	transfer_from CHAR(20) NOT NULL,
+	transfer_to CHAR(20) NOT NULL,
+	token_id TEXT NOT NULL,
+	quantity TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, log_index, batch_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+CREATE INDEX nft_transfers_token_idx ON nft_transfers (collection, token_id, block_number);
+CREATE INDEX nft_transfers_from_idx ON nft_transfers (transfer_from, block_number);
+CREATE INDEX nft_transfers_to_idx ON nft_transfers (transfer_to, block_number);
@@ -0,0 +1,11 @@
+-- balance deltas table
+CREATE TABLE balance_deltas (
+	block_number INT NOT NULL,
+	address CHAR(20) NOT NULL,
+	delta TEXT NOT NULL, -- wei, base 10, signed
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (block_number, address),
+	FOREIGN KEY (block_number) REFERENCES blocks (block_number) ON DELETE CASCADE
+);
+
+CREATE INDEX balance_deltas_address_idx ON balance_deltas (address, block_number);
@@ -0,0 +1,13 @@
+-- withdrawals table
+CREATE TABLE withdrawals (
+	withdrawal_index INT PRIMARY KEY,
+	block_number INT NOT NULL,
+	validator_index INT NOT NULL,
+	address CHAR(20) NOT NULL,
+	amount INT NOT NULL, -- gwei
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (block_number) REFERENCES blocks (block_number) ON DELETE CASCADE
+);
+
+CREATE INDEX withdrawals_block_idx ON withdrawals (block_number);
+CREATE INDEX withdrawals_address_idx ON withdrawals (address, block_number);
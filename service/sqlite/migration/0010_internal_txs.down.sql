@@ -0,0 +1 @@
+DROP TABLE internal_txs;
@@ -0,0 +1,18 @@
+-- internal transactions table
+CREATE TABLE internal_txs (
+	tx_hash CHAR(32) NOT NULL,
+	trace_index INT NOT NULL,
+	block_number INT NOT NULL,
+	depth INT NOT NULL,
+	call_type TEXT NOT NULL,
+	tx_from CHAR(20) NOT NULL,
+	tx_to CHAR(20) NOT NULL,
+	amount TEXT NOT NULL, -- wei, base 10
+	error TEXT,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (tx_hash, trace_index),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+CREATE INDEX internal_txs_from_idx ON internal_txs (tx_from, block_number);
+CREATE INDEX internal_txs_to_idx ON internal_txs (tx_to, block_number);
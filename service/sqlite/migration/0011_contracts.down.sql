@@ -0,0 +1 @@
+DROP TABLE contracts;
@@ -0,0 +1,14 @@
+-- contracts table, an address is deployed again after a
+-- self-destruct (e.g. CREATE2), every deployment is kept.
+CREATE TABLE contracts (
+	address CHAR(20) NOT NULL,
+	deployer CHAR(20) NOT NULL,
+	tx_hash CHAR(32) NOT NULL,
+	block_number INT NOT NULL,
+	code_hash CHAR(32), -- NULL when the source has no state
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	PRIMARY KEY (address, tx_hash),
+	FOREIGN KEY (tx_hash) REFERENCES transactions (tx_hash) ON DELETE CASCADE
+);
+
+CREATE INDEX contracts_deployer_idx ON contracts (deployer, block_number);
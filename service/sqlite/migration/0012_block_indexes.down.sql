@@ -0,0 +1,2 @@
+DROP INDEX transactions_block_idx;
+DROP INDEX blocks_timestamp_idx;
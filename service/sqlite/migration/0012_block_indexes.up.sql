@@ -0,0 +1,3 @@
+-- latest block & per block tx lookups
+CREATE INDEX blocks_timestamp_idx ON blocks (mined_timestamp);
+CREATE INDEX transactions_block_idx ON transactions (block_number, tx_order);
@@ -1,2 +0,0 @@
-DROP TABLE IF EXISTS transactions;
-DROP TABLE IF EXISTS blocks;
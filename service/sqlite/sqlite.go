@@ -1,26 +1,27 @@
 package sqlite
 
 import (
-	"context"
 	"database/sql"
-	_ "embed"
-	"fmt"
+	"embed"
+	"errors"
+	"io/fs"
 	"os"
 	"path"
+	"runtime"
+	"time"
 
-	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/migrate"
+	"github.com/twiny/blockscan/service/sqlstore"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-//go:embed migration/schema.up.sql
-var schemaUp string
-
-//go:embed migration/schema.down.sql
-var schemaDown string
+//go:embed migration/*.sql
+var migrations embed.FS
 
 // SQLite
 type SQLite struct {
+	*sqlstore.Store
 	db *sql.DB
 }
 
@@ -43,7 +44,10 @@ func NewSQLiteDB(dir string) (*SQLite, error) {
 		f.Close()
 	}
 
-	db, err := sql.Open("sqlite3", storefile+"?cache=shared_sync=1&_cache_size=25000")
+	// immediate transactions take the write lock on begin, so concurrent
+	// block batches wait on each other instead of failing to upgrade.
+	// WAL lets readers, e.g. the rest service, run alongside the writer.
+	db, err := sql.Open("sqlite3", storefile+"?cache=shared_sync=1&_cache_size=25000&_foreign_keys=1&_txlock=immediate&_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
 		return nil, err
 	}
@@ -54,245 +58,60 @@ func NewSQLiteDB(dir string) (*SQLite, error) {
 	}
 
 	return &SQLite{
-		db: db,
+		Store: sqlstore.New(db, queries),
+		db:    db,
 	}, nil
 }
 
-// Migrate
-func (s *SQLite) Migrate(cmd string) error {
-	switch cmd {
-	case "up":
-		_, err := s.db.ExecContext(context.Background(), schemaUp)
-		return err
-	case "down":
-		_, err := s.db.ExecContext(context.Background(), schemaDown)
-		return err
-	default:
-		return fmt.Errorf("unknown command")
-	}
-}
-
-// Ping
-func (s *SQLite) Ping() error {
-	return s.db.Ping()
-}
-
-// Rest service \\
-
-// GetLatestBlock
-func (s *SQLite) GetLatestBlock(ctx context.Context) (*chain.Block, error) {
-	// get latest block
-	var b chain.Block
-	if err := s.db.QueryRowContext(
-		ctx,
-		selectLatestBlock,
-	).Scan(
-		&b.Number,
-		&b.Hash,
-		&b.Timestamp,
-		&b.TxCount,
-	); err != nil {
-		return nil, err
-	}
-
-	// get block transaction
-	rows, err := s.db.QueryContext(
-		ctx,
-		selectAllTxsHashsByBlockID,
-		b.Number,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var txs = []string{}
-
-	for rows.Next() {
-		var tx string
-		if err := rows.Scan(&tx); err != nil {
-			return nil, err
-		}
-
-		txs = append(txs, tx)
+// NewSQLiteReader opens the store in dir read-only, it is neither
+// created nor migrated. conns caps the connection pool, 0 for the
+// number of CPUs. Queries fail with an error reported by Unavailable
+// while the file is missing or locked.
+func NewSQLiteReader(dir string, conns int) (*SQLite, error) {
+	if conns <= 0 {
+		conns = runtime.NumCPU()
 	}
 
-	b.Txs = txs
-
-	return &b, nil
-}
-
-// GetBlock
-func (s *SQLite) GetBlock(ctx context.Context, n int64) (*chain.Block, error) {
-	// get block
-	var b chain.Block
-	if err := s.db.QueryRowContext(
-		ctx,
-		selectBlock,
-		n,
-	).Scan(
-		&b.Number,
-		&b.Hash,
-		&b.Timestamp,
-		&b.TxCount,
-	); err != nil {
-		return nil, err
-	}
+	storefile := path.Join(dir, "store.db")
 
-	// get block transaction
-	rows, err := s.db.QueryContext(
-		ctx,
-		selectAllTxsHashsByBlockID,
-		b.Number,
-	)
+	// a short busy timeout, a request fails fast rather than queue
+	// behind a checkpoint; the journal mode is set by the writer.
+	db, err := sql.Open("sqlite3", "file:"+storefile+"?mode=ro&_cache_size=25000&_busy_timeout=1000")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var txs = []string{}
-
-	for rows.Next() {
-		var tx string
-		if err := rows.Scan(&tx); err != nil {
-			return nil, err
-		}
-
-		txs = append(txs, tx)
-	}
 
-	b.Txs = txs
+	db.SetMaxOpenConns(conns)
+	db.SetMaxIdleConns(conns)
+	db.SetConnMaxIdleTime(5 * time.Minute)
 
-	return &b, nil
+	return &SQLite{
+		Store: sqlstore.New(db, queries),
+		db:    db,
+	}, nil
 }
 
-// GetLatestTx
-func (s *SQLite) GetLatestTx(ctx context.Context) (*chain.Tx, error) {
-	var t chain.Tx
-	if err := s.db.QueryRowContext(
-		ctx,
-		selectLatestTx,
-	).Scan(
-		&t.Hash,
-		&t.BlockNumber,
-		&t.From,
-		&t.To,
-		&t.Amount,
-		&t.Nonce,
-		&t.Timestamp,
-		&t.Order,
-	); err != nil {
-		return nil, err
+// Unavailable whether err is due to the database file being missing or locked.
+func (s *SQLite) Unavailable(err error) bool {
+	var serr sqlite3.Error
+	if !errors.As(err, &serr) {
+		return false
 	}
-	return &t, nil
-}
 
-// GetTx
-func (s *SQLite) GetTx(ctx context.Context, hash string) (*chain.Tx, error) {
-	var t chain.Tx
-	if err := s.db.QueryRowContext(
-		ctx,
-		selectTx,
-		hash,
-	).Scan(
-		&t.Hash,
-		&t.BlockNumber,
-		&t.From,
-		&t.To,
-		&t.Amount,
-		&t.Nonce,
-		&t.Timestamp,
-		&t.Order,
-	); err != nil {
-		return nil, err
+	switch serr.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrCantOpen:
+		return true
+	default:
+		return false
 	}
-	return &t, nil
 }
 
-// GetRangeStats
-func (s *SQLite) GetStats(ctx context.Context, i, j int64) (*chain.Stats, error) {
-	var status = &chain.Stats{
-		Txs:         []string{},
-		TotalAmount: 0,
-	}
-
-	rows, err := s.db.QueryContext(ctx, selectAllTxHash, i, j)
+// Migrator the versioned schema migrations of the store.
+func (s *SQLite) Migrator() (*migrate.Migrator, error) {
+	fsys, err := fs.Sub(migrations, "migration")
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return status, nil
-		}
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var tx string
-		if err := rows.Scan(&tx); err != nil {
-			return nil, err
-		}
-
-		status.Txs = append(status.Txs, tx)
-	}
-
-	var total float64
-	if err := s.db.QueryRowContext(ctx, selectSumOfAllTx, i, j).Scan(&total); err != nil {
 		return nil, err
 	}
 
-	status.TotalAmount = total
-
-	return status, nil
-}
-
-// Indexer service \\
-
-// HasScanned
-func (s *SQLite) HasScanned(ctx context.Context, id int64) bool {
-	var found = 0
-	if err := s.db.QueryRowContext(
-		ctx,
-		hasScanned,
-		id,
-	).Scan(&found); err != nil {
-		return false
-	}
-
-	return found != 0
-}
-
-// SaveBlock
-func (s *SQLite) SaveBlock(ctx context.Context, b *chain.Block) error {
-	_, err := s.db.ExecContext(
-		ctx,
-		insertBlock,
-		b.Number,
-		b.Hash,
-		b.Timestamp,
-		b.TxCount,
-	)
-	return err
-}
-
-// SaveTx
-func (s *SQLite) SaveTx(ctx context.Context, tx *chain.Tx) error {
-	_, err := s.db.ExecContext(
-		ctx,
-		insertTx,
-		tx.Hash,
-		tx.BlockNumber,
-		tx.From,
-		tx.To,
-		tx.Amount,
-		tx.Nonce,
-		tx.Timestamp,
-		tx.Order,
-	)
-
-	return err
-}
-
-// // \\ \\
-// Close
-func (s *SQLite) Close() error {
-	return s.db.Close()
+	return migrate.New(s.db, fsys)
 }
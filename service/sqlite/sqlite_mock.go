@@ -1,6 +0,0 @@
-package sqlite
-
-// SQLiteMock
-type SQLiteMock struct {
-	// TODO Add mocks
-}
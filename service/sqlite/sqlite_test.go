@@ -1 +1,649 @@
 package sqlite
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/migrate"
+	"github.com/twiny/blockscan/service/storetest"
+)
+
+// newTestSQLite
+func newTestSQLite(t *testing.T) *SQLite {
+	t.Helper()
+
+	s, err := NewSQLiteDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	m, err := s.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+// TestGetGaps
+func TestGetGaps(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	for _, n := range []int64{12, 13, 15, 18} {
+		if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+			Block: &chain.Block{
+				Number:    n,
+				Hash:      "0x",
+				Timestamp: time.Now(),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		i, j int64
+		want []*chain.Gap
+	}{
+		{
+			name: "TestInnerGaps",
+			i:    12,
+			j:    18,
+			want: []*chain.Gap{{From: 14, To: 14}, {From: 16, To: 17}},
+		},
+		{
+			name: "TestEdgeGaps",
+			i:    10,
+			j:    20,
+			want: []*chain.Gap{{From: 10, To: 11}, {From: 14, To: 14}, {From: 16, To: 17}, {From: 19, To: 20}},
+		},
+		{
+			name: "TestNoGaps",
+			i:    12,
+			j:    13,
+			want: []*chain.Gap{},
+		},
+		{
+			name: "TestEmptyRange",
+			i:    30,
+			j:    32,
+			want: []*chain.Gap{{From: 30, To: 32}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gaps, err := s.GetGaps(ctx, tc.i, tc.j)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(gaps, tc.want) {
+				t.Errorf("got %+v, want %+v", gaps, tc.want)
+			}
+		})
+	}
+}
+
+// TestGetLogs
+func TestGetLogs(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block: &chain.Block{Number: 1, Hash: "0x1", Timestamp: time.Now()},
+		Txs: []*chain.Tx{{
+			Hash:        "0xt",
+			BlockNumber: 1,
+			Amount:      "0",
+			Timestamp:   time.Now(),
+			Receipt: &chain.Receipt{
+				TxHash:      "0xt",
+				BlockNumber: 1,
+				Status:      1,
+				Logs: []*chain.Log{
+					{TxHash: "0xt", BlockNumber: 1, Index: 0, Address: "0xa", Topics: []string{"0x01", "0x02"}, Data: "0x"},
+					{TxHash: "0xt", BlockNumber: 1, Index: 1, Address: "0xb", Topics: []string{"0x01", "0x03"}, Data: "0x"},
+					{TxHash: "0xt", BlockNumber: 1, Index: 2, Address: "0xb", Topics: []string{}, Data: "0x"},
+				},
+			},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter *chain.LogFilter
+		want   []uint
+	}{
+		{
+			name:   "TestAll",
+			filter: &chain.LogFilter{FromBlock: 0, ToBlock: 1, Limit: 10},
+			want:   []uint{0, 1, 2},
+		},
+		{
+			name:   "TestAddress",
+			filter: &chain.LogFilter{FromBlock: 0, ToBlock: 1, Addresses: []string{"0xb"}, Limit: 10},
+			want:   []uint{1, 2},
+		},
+		{
+			name:   "TestTopics",
+			filter: &chain.LogFilter{FromBlock: 0, ToBlock: 1, Topics: [4][]string{{"0x01"}, {"0x02", "0x03"}}, Limit: 10},
+			want:   []uint{0, 1},
+		},
+		{
+			name:   "TestRange",
+			filter: &chain.LogFilter{FromBlock: 2, ToBlock: 3, Limit: 10},
+			want:   []uint{},
+		},
+		{
+			name:   "TestLimit",
+			filter: &chain.LogFilter{FromBlock: 0, ToBlock: 1, Limit: 1},
+			want:   []uint{0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			logs, err := s.GetLogs(ctx, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got = []uint{}
+			for _, l := range logs {
+				got = append(got, l.Index)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestGetAddressTxs
+func TestGetAddressTxs(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	var batches = map[int64]*chain.BlockBatch{}
+
+	for _, tx := range []*chain.Tx{
+		{Hash: "0x1", BlockNumber: 1, From: "0xa", To: "0xb", Order: 0},
+		{Hash: "0x2", BlockNumber: 1, From: "0xb", To: "0xa", Order: 1},
+		{Hash: "0x3", BlockNumber: 2, From: "0xa", To: "0xc", Order: 0},
+		{Hash: "0x4", BlockNumber: 2, From: "0xc", To: "0xb", Order: 1},
+	} {
+		tx.Amount = "0"
+		tx.Timestamp = time.Now()
+
+		bb, found := batches[tx.BlockNumber]
+		if !found {
+			bb = &chain.BlockBatch{Block: &chain.Block{Number: tx.BlockNumber, Hash: "0x", Timestamp: time.Now()}}
+			batches[tx.BlockNumber] = bb
+		}
+		bb.Txs = append(bb.Txs, tx)
+	}
+
+	for _, bb := range batches {
+		if err := s.SaveBlockBatch(ctx, bb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter *chain.AddressTxFilter
+		want   []string
+	}{
+		{
+			name:   "TestAll",
+			filter: &chain.AddressTxFilter{Address: "0xa", Direction: chain.DirectionAll, FromBlock: 0, ToBlock: 2, Limit: 10},
+			want:   []string{"0x3", "0x2", "0x1"},
+		},
+		{
+			name:   "TestIn",
+			filter: &chain.AddressTxFilter{Address: "0xb", Direction: chain.DirectionIn, FromBlock: 0, ToBlock: 2, Limit: 10},
+			want:   []string{"0x4", "0x1"},
+		},
+		{
+			name:   "TestOut",
+			filter: &chain.AddressTxFilter{Address: "0xa", Direction: chain.DirectionOut, FromBlock: 0, ToBlock: 2, Limit: 10},
+			want:   []string{"0x3", "0x1"},
+		},
+		{
+			name:   "TestRange",
+			filter: &chain.AddressTxFilter{Address: "0xa", Direction: chain.DirectionAll, FromBlock: 2, ToBlock: 2, Limit: 10},
+			want:   []string{"0x3"},
+		},
+		{
+			name:   "TestCursor",
+			filter: &chain.AddressTxFilter{Address: "0xa", Direction: chain.DirectionAll, FromBlock: 0, ToBlock: 2, Cursor: &chain.TxCursor{BlockNumber: 1, Order: 1}, Limit: 10},
+			want:   []string{"0x1"},
+		},
+		{
+			name:   "TestLimit",
+			filter: &chain.AddressTxFilter{Address: "0xb", Direction: chain.DirectionAll, FromBlock: 0, ToBlock: 2, Limit: 2},
+			want:   []string{"0x4", "0x2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			txs, err := s.GetAddressTxs(ctx, tc.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got = []string{}
+			for _, tx := range txs {
+				got = append(got, tx.Hash)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestGetTx
+func TestGetTx(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	want := &chain.Tx{
+		Hash:                 "0xt",
+		BlockNumber:          1,
+		From:                 "0xa",
+		To:                   "0xb",
+		Amount:               "1",
+		Type:                 3,
+		GasLimit:             21000,
+		GasPrice:             "3",
+		MaxFeePerGas:         "3",
+		MaxPriorityFeePerGas: "1",
+		Input:                "0x",
+		AccessList: []*chain.AccessTuple{
+			{Address: "0xc", StorageKeys: []string{"0x01", "0x02"}},
+			{Address: "0xd", StorageKeys: []string{}},
+		},
+		MaxFeePerBlobGas: "5",
+		BlobHashes:       []string{"0x0101", "0x0102"},
+	}
+
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block: &chain.Block{Number: 1, Hash: "0x1", Timestamp: time.Now()},
+		Txs:   []*chain.Tx{want},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetTx(ctx, want.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// timestamps lose their monotonic clock in the db
+	got.Timestamp = want.Timestamp
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// TestWithdrawals
+func TestWithdrawals(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	for _, b := range []*chain.Block{
+		{Number: 1, Hash: "0x1", Withdrawals: []*chain.Withdrawal{
+			{Index: 0, BlockNumber: 1, ValidatorIndex: 7, Address: "0xa", Amount: 100},
+			{Index: 1, BlockNumber: 1, ValidatorIndex: 8, Address: "0xb", Amount: 200},
+		}},
+		{Number: 2, Hash: "0x2", Withdrawals: []*chain.Withdrawal{
+			{Index: 2, BlockNumber: 2, ValidatorIndex: 7, Address: "0xa", Amount: 300},
+		}},
+		{Number: 3, Hash: "0x3"},
+	} {
+		b.Timestamp = time.Now()
+		if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{Block: b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	block, err := s.GetBlock(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(block.Withdrawals) != 2 || block.Withdrawals[1].Amount != 200 {
+		t.Errorf("unexpected withdrawals %+v", block.Withdrawals)
+	}
+
+	block, err = s.GetBlock(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Withdrawals != nil {
+		t.Errorf("got withdrawals %+v, want none", block.Withdrawals)
+	}
+
+	withdrawals, err := s.GetAddressWithdrawals(ctx, "0xa", 0, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got = []uint64{}
+	for _, w := range withdrawals {
+		got = append(got, w.Index)
+	}
+
+	if !reflect.DeepEqual(got, []uint64{2, 0}) {
+		t.Errorf("got %v, want [2 0]", got)
+	}
+}
+
+// TestInternalTxs
+func TestInternalTxs(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	want := []*chain.InternalTx{
+		{TxHash: "0xt", BlockNumber: 1, Index: 0, Depth: 1, Type: "CALL", From: "0xb", To: "0xc", Value: "16"},
+		{TxHash: "0xt", BlockNumber: 1, Index: 1, Depth: 2, Type: "CALL", From: "0xc", To: "0xd", Value: "0", Error: "execution reverted"},
+	}
+
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block:       &chain.Block{Number: 1, Hash: "0x1", Timestamp: time.Now()},
+		Txs:         []*chain.Tx{{Hash: "0xt", BlockNumber: 1, Amount: "0", Timestamp: time.Now()}},
+		InternalTxs: want,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.GetInternalTxs(ctx, "0xt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = s.GetAddressInternalTxs(ctx, "0xd", 0, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Index != 1 {
+		t.Errorf("unexpected address internal txs %+v", got)
+	}
+}
+
+// TestSaveBlockBatch
+func TestSaveBlockBatch(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	tx := &chain.Tx{
+		Hash:        "0xt",
+		BlockNumber: 1,
+		Amount:      "0",
+		Timestamp:   time.Now(),
+		Receipt:     &chain.Receipt{TxHash: "0xt", BlockNumber: 1, Status: 1, EffectiveGasPrice: "1"},
+	}
+
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block:         &chain.Block{Number: 1, Hash: "0x1", Timestamp: time.Now()},
+		Txs:           []*chain.Tx{tx},
+		BalanceDeltas: []*chain.BalanceDelta{{BlockNumber: 1, Address: "0xa", Delta: "1"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.HasScanned(ctx, 1) {
+		t.Fatal("block 1 not saved")
+	}
+
+	if _, err := s.GetTx(ctx, "0xt"); err != nil {
+		t.Fatal(err)
+	}
+
+	// duplicate tx fails the whole batch
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block: &chain.Block{Number: 2, Hash: "0x2", Timestamp: time.Now()},
+		Txs:   []*chain.Tx{{Hash: "0xu", BlockNumber: 2, Amount: "0", Timestamp: time.Now()}, tx},
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if s.HasScanned(ctx, 2) {
+		t.Error("block 2 saved, want rolled back")
+	}
+
+	if _, err := s.GetTx(ctx, "0xu"); err == nil {
+		t.Error("tx 0xu saved, want rolled back")
+	}
+}
+
+// TestStore
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Store {
+		return newTestSQLite(t)
+	})
+}
+
+// TestMigrations every migration reverts cleanly & reapplies.
+func TestMigrations(t *testing.T) {
+	s := newTestSQLite(t)
+
+	ctx := context.Background()
+
+	m, err := s.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Goto(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	var tables int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence');`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+
+	if tables != 0 {
+		t.Errorf("got %d tables, want none", tables)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+// baselineSchema of the store before versioning, as created by `Migrate("up")`.
+const baselineSchema = `
+CREATE TABLE IF NOT EXISTS blocks (
+	block_number INT PRIMARY KEY,
+	block_hash CHAR(32) NOT NULL,
+	mined_timestamp TIMESTAMP NOT NULL,
+	tx_count INT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+	tx_hash CHAR(32) NOT NULL PRIMARY KEY,
+	block_number INT NOT NULL,
+	tx_from CHAR(32) NOT NULL,
+	tx_to CHAR(32) NOT NULL,
+	amount NUMERIC NOT NULL,
+	nonce INT NOT NULL,
+	mined_timestamp TIMESTAMP NOT NULL,
+	tx_order INT NOT NULL,
+	created_at TIMESTAMP DEFAULT current_timestamp,
+	FOREIGN KEY (block_number) REFERENCES blocks (block_number) ON DELETE CASCADE
+);
+
+INSERT INTO blocks (block_number, block_hash, mined_timestamp, tx_count) VALUES (1, '0x1', '2022-10-02 19:50:05', 1);
+INSERT INTO transactions (tx_hash, block_number, tx_from, tx_to, amount, nonce, mined_timestamp, tx_order)
+VALUES ('0xt', 1, '0xa', '0xb', 1000000000000000000, 7, '2022-10-02 19:50:05', 0);
+`
+
+// TestMigrationsBaseline a database created before versioning is
+// adopted at version 1 & upgraded, keeping its blocks & txs.
+func TestMigrationsBaseline(t *testing.T) {
+	s, err := NewSQLiteDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+
+	if _, err := s.db.ExecContext(ctx, baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := s.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); !errors.Is(err, migrate.ErrOutOfDate) {
+		t.Fatalf("got %v, want ErrOutOfDate", err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := s.GetTx(ctx, "0xt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Amount != "1000000000000000000" || tx.To != "0xb" || tx.Nonce != 7 || tx.BlockNumber != 1 {
+		t.Errorf("got tx %+v", tx)
+	}
+
+	block, err := s.GetBlock(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Hash != "0x1" || !reflect.DeepEqual(block.Txs, []string{"0xt"}) {
+		t.Errorf("got block %+v", block)
+	}
+
+	// indexed after the upgrade
+	if err := s.SaveBlockBatch(ctx, &chain.BlockBatch{
+		Block: &chain.Block{Number: 2, Hash: "0x2", ParentHash: "0x1", Difficulty: "0", Timestamp: time.Now()},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// back to the baseline
+	if err := m.Goto(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var amount int64
+	if err := s.db.QueryRowContext(ctx, `SELECT amount FROM transactions WHERE tx_hash = '0xt';`).Scan(&amount); err != nil {
+		t.Fatal(err)
+	}
+
+	if amount != 1000000000000000000 {
+		t.Errorf("got amount %d after reverting", amount)
+	}
+}
+
+// TestReader
+func TestReader(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx := context.Background()
+
+	r, err := NewSQLiteReader(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// not created by the reader
+	if _, err := r.GetLatestBlock(ctx); !r.Unavailable(err) {
+		t.Fatalf("got %v, want unavailable", err)
+	}
+
+	w, err := NewSQLiteDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	m, err := w.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.SaveBlockBatch(ctx, &chain.BlockBatch{Block: &chain.Block{Number: 1, Hash: "0x1", Timestamp: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := r.GetLatestBlock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Number != 1 {
+		t.Errorf("got block %d, want 1", block.Number)
+	}
+
+	if err := r.SaveBlockBatch(ctx, &chain.BlockBatch{Block: &chain.Block{Number: 2, Hash: "0x2", Timestamp: time.Now()}}); err == nil {
+		t.Error("reader saved a block")
+	}
+
+	rm, err := r.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rm.Check(ctx); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,118 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// execer executes a write, either directly on the database
+// or inside a batch transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// batch a write transaction, each query is prepared
+// once and reused for all the rows of the batch.
+type batch struct {
+	tx    *sql.Tx
+	stmts map[string]*sql.Stmt
+}
+
+// ExecContext
+func (b *batch) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt, found := b.stmts[query]
+	if !found {
+		var err error
+		stmt, err = b.tx.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		b.stmts[query] = stmt
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+// close prepared statements
+func (b *batch) close() {
+	for _, stmt := range b.stmts {
+		stmt.Close()
+	}
+}
+
+// SaveBlockBatch saves a block along with its transactions, receipts, logs,
+// internal txs, contracts, token metadata, transfers & balance changes in
+// one transaction. Nothing is saved on error, so the block is not marked
+// as scanned.
+func (s *Store) SaveBlockBatch(ctx context.Context, bb *chain.BlockBatch) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	b := &batch{
+		tx:    tx,
+		stmts: map[string]*sql.Stmt{},
+	}
+	defer b.close()
+
+	if err := s.writeBlockBatch(ctx, b, bb); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// writeBlockBatch
+func (s *Store) writeBlockBatch(ctx context.Context, e execer, bb *chain.BlockBatch) error {
+	if err := s.saveBlock(ctx, e, bb.Block); err != nil {
+		return err
+	}
+
+	for _, t := range bb.Txs {
+		if err := s.saveTx(ctx, e, t); err != nil {
+			return err
+		}
+
+		if t.Receipt == nil {
+			continue
+		}
+
+		if err := s.saveReceipt(ctx, e, t.Receipt); err != nil {
+			return err
+		}
+	}
+
+	if err := s.saveInternalTxs(ctx, e, bb.InternalTxs); err != nil {
+		return err
+	}
+
+	for _, c := range bb.Contracts {
+		if err := s.saveContract(ctx, e, c); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range bb.Tokens {
+		if err := s.saveToken(ctx, e, t); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range bb.TokenTransfers {
+		if err := s.saveTokenTransfer(ctx, e, t); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range bb.NFTTransfers {
+		if err := s.saveNFTTransfer(ctx, e, t); err != nil {
+			return err
+		}
+	}
+
+	return s.saveBalanceDeltas(ctx, e, bb.BalanceDeltas)
+}
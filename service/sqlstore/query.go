@@ -0,0 +1,63 @@
+package sqlstore
+
+// Queries of a store, in the placeholder syntax of its driver.
+type Queries struct {
+	// Placeholder of the nth argument of a query, from 1.
+	Placeholder func(n int) string
+
+	SelectLatestBlock           string
+	SelectBlock                 string
+	SelectAllTxsHashsByBlockID  string
+	SelectWithdrawalsByBlockID  string
+	SelectAddressWithdrawals    string
+	SelectLatestTx              string
+	SelectTx                    string
+	SelectAddressTxs            string
+	SelectAccessList            string
+	SelectAccessListKeys        string
+	SelectBlobHashes            string
+	SelectInternalTxs           string
+	SelectAddressInternalTxs    string
+	SelectReceipt               string
+	SelectLogsByTx              string
+	SelectLogs                  string
+	SelectToken                 string
+	SelectTokenTransfers        string
+	SelectAddressTokenTransfers string
+	SelectNFTTransfers          string
+	SelectNFTTokenTransfers     string
+	// SelectBalance the balance deltas of an address,
+	// or their sum, see Store.sum.
+	SelectBalance           string
+	SelectBalanceAddresses  string
+	SelectContract          string
+	SelectDeployerContracts string
+	SelectTxHashes          string
+	// SelectTxAmounts the amounts of the txs in a range,
+	// or their sum, see Store.sum.
+	SelectTxAmounts       string
+	SelectGaps            string
+	HasScanned            string
+	SelectBlockHash       string
+	SelectTotalDifficulty string
+	UpdateTotalDifficulty string
+	DeleteBlocks          string
+	InsertBlock           string
+	InsertWithdrawal      string
+	InsertTx              string
+	InsertAccessList      string
+	InsertAccessListKey   string
+	InsertBlobHash        string
+	SelectScanRanges      string
+	InsertScanRange       string
+	UpdateScanRange       string
+	HasToken              string
+	InsertToken           string
+	InsertTokenTransfer   string
+	InsertNFTTransfer     string
+	InsertInternalTx      string
+	InsertReceipt         string
+	InsertLog             string
+	InsertBalanceDelta    string
+	InsertContract        string
+}
@@ -0,0 +1,1290 @@
+// Package sqlstore implements the store on database/sql, shared by the
+// sqlite & postgres drivers which differ only in their queries.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/twiny/blockscan/pkg/chain"
+	"github.com/twiny/blockscan/pkg/utils"
+)
+
+// Store
+type Store struct {
+	db *sql.DB
+	q  *Queries
+}
+
+// New a store on db, running the queries of its driver.
+func New(db *sql.DB, q *Queries) *Store {
+	return &Store{
+		db: db,
+		q:  q,
+	}
+}
+
+// Ping
+func (s *Store) Ping() error {
+	return s.db.Ping()
+}
+
+// Rest service \\
+
+// GetLatestBlock
+func (s *Store) GetLatestBlock(ctx context.Context) (*chain.Block, error) {
+	// get latest block
+	var b chain.Block
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectLatestBlock,
+	).Scan(
+		&b.Number,
+		&b.Hash,
+		&b.ParentHash,
+		&b.Miner,
+		&b.Timestamp,
+		&b.GasLimit,
+		&b.GasUsed,
+		&b.BaseFee,
+		&b.Difficulty,
+		&b.TotalDifficulty,
+		&b.ExtraData,
+		&b.Size,
+		&b.StateRoot,
+		&b.WithdrawalsRoot,
+		&b.TxCount,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := s.getBlockDetails(ctx, &b); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// GetBlock
+func (s *Store) GetBlock(ctx context.Context, n int64) (*chain.Block, error) {
+	// get block
+	var b chain.Block
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectBlock,
+		n,
+	).Scan(
+		&b.Number,
+		&b.Hash,
+		&b.ParentHash,
+		&b.Miner,
+		&b.Timestamp,
+		&b.GasLimit,
+		&b.GasUsed,
+		&b.BaseFee,
+		&b.Difficulty,
+		&b.TotalDifficulty,
+		&b.ExtraData,
+		&b.Size,
+		&b.StateRoot,
+		&b.WithdrawalsRoot,
+		&b.TxCount,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := s.getBlockDetails(ctx, &b); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+// getBlockDetails attaches the transactions hash & withdrawals of b.
+func (s *Store) getBlockDetails(ctx context.Context, b *chain.Block) error {
+	// get block transaction
+	rows, err := s.db.QueryContext(
+		ctx,
+		s.q.SelectAllTxsHashsByBlockID,
+		b.Number,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var txs = []string{}
+
+	for rows.Next() {
+		var tx string
+		if err := rows.Scan(&tx); err != nil {
+			return err
+		}
+
+		txs = append(txs, tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// get block withdrawals
+	withdrawals, err := s.db.QueryContext(
+		ctx,
+		s.q.SelectWithdrawalsByBlockID,
+		b.Number,
+	)
+	if err != nil {
+		return err
+	}
+	defer withdrawals.Close()
+
+	ws, err := scanWithdrawals(withdrawals)
+	if err != nil {
+		return err
+	}
+
+	b.Txs = txs
+	if len(ws) > 0 {
+		b.Withdrawals = ws
+	}
+
+	return nil
+}
+
+// GetAddressWithdrawals withdrawals to an address in range [i, j], newest first.
+func (s *Store) GetAddressWithdrawals(ctx context.Context, address string, i, j int64, limit int) ([]*chain.Withdrawal, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectAddressWithdrawals, address, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWithdrawals(rows)
+}
+
+// scanWithdrawals
+func scanWithdrawals(rows *sql.Rows) ([]*chain.Withdrawal, error) {
+	var withdrawals = []*chain.Withdrawal{}
+
+	for rows.Next() {
+		var w chain.Withdrawal
+		if err := rows.Scan(
+			&w.Index,
+			&w.BlockNumber,
+			&w.ValidatorIndex,
+			&w.Address,
+			&w.Amount,
+		); err != nil {
+			return nil, err
+		}
+
+		withdrawals = append(withdrawals, &w)
+	}
+
+	return withdrawals, rows.Err()
+}
+
+// GetLatestTx
+func (s *Store) GetLatestTx(ctx context.Context) (*chain.Tx, error) {
+	var t chain.Tx
+	if err := scanTx(s.db.QueryRowContext(
+		ctx,
+		s.q.SelectLatestTx,
+	), &t); err != nil {
+		return nil, err
+	}
+
+	if err := s.getTxDetails(ctx, &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// GetTx
+func (s *Store) GetTx(ctx context.Context, hash string) (*chain.Tx, error) {
+	var t chain.Tx
+	if err := scanTx(s.db.QueryRowContext(
+		ctx,
+		s.q.SelectTx,
+		hash,
+	), &t); err != nil {
+		return nil, err
+	}
+
+	if err := s.getTxDetails(ctx, &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// scanner a *sql.Row or *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTx
+func scanTx(row scanner, t *chain.Tx) error {
+	return row.Scan(
+		&t.Hash,
+		&t.BlockNumber,
+		&t.From,
+		&t.To,
+		&t.Amount,
+		&t.Nonce,
+		&t.Timestamp,
+		&t.Order,
+		&t.Type,
+		&t.GasLimit,
+		&t.GasPrice,
+		&t.MaxFeePerGas,
+		&t.MaxPriorityFeePerGas,
+		&t.MaxFeePerBlobGas,
+		&t.Input,
+	)
+}
+
+// getTxDetails attaches the access list, blob hashes & receipt of t.
+func (s *Store) getTxDetails(ctx context.Context, t *chain.Tx) error {
+	accessList, err := s.getAccessList(ctx, t.Hash)
+	if err != nil {
+		return err
+	}
+
+	blobHashes, err := s.getBlobHashes(ctx, t.Hash)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := s.getReceipt(ctx, t.Hash)
+	if err != nil {
+		return err
+	}
+
+	t.AccessList = accessList
+	t.BlobHashes = blobHashes
+	t.Receipt = receipt
+
+	return nil
+}
+
+// getAccessList nil when the tx has none.
+func (s *Store) getAccessList(ctx context.Context, hash string) ([]*chain.AccessTuple, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectAccessList, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		accessList []*chain.AccessTuple
+		entries    = map[int]*chain.AccessTuple{}
+	)
+
+	for rows.Next() {
+		var (
+			i int
+			a = &chain.AccessTuple{StorageKeys: []string{}}
+		)
+
+		if err := rows.Scan(&i, &a.Address); err != nil {
+			return nil, err
+		}
+
+		entries[i] = a
+		accessList = append(accessList, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(accessList) == 0 {
+		return nil, nil
+	}
+
+	keys, err := s.db.QueryContext(ctx, s.q.SelectAccessListKeys, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer keys.Close()
+
+	for keys.Next() {
+		var (
+			i   int
+			key string
+		)
+
+		if err := keys.Scan(&i, &key); err != nil {
+			return nil, err
+		}
+
+		if a, found := entries[i]; found {
+			a.StorageKeys = append(a.StorageKeys, key)
+		}
+	}
+
+	return accessList, keys.Err()
+}
+
+// getBlobHashes nil when the tx carries no blobs.
+func (s *Store) getBlobHashes(ctx context.Context, hash string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectBlobHashes, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+
+	for rows.Next() {
+		var h string
+		if err := rows.Scan(&h); err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, h)
+	}
+
+	return hashes, rows.Err()
+}
+
+// GetAddressTxs transactions from and/or to an address, newest first.
+func (s *Store) GetAddressTxs(ctx context.Context, f *chain.AddressTxFilter) ([]*chain.Tx, error) {
+	var (
+		query strings.Builder
+		args  = []any{f.FromBlock, f.ToBlock}
+	)
+
+	query.WriteString(s.q.SelectAddressTxs)
+
+	switch f.Direction {
+	case chain.DirectionIn:
+		query.WriteString("AND t1.tx_to = " + s.param(&args, f.Address) + "\n")
+	case chain.DirectionOut:
+		query.WriteString("AND t1.tx_from = " + s.param(&args, f.Address) + "\n")
+	default:
+		n := s.param(&args, f.Address)
+		query.WriteString("AND (t1.tx_from = " + n + " OR t1.tx_to = " + s.param(&args, f.Address) + ")\n")
+	}
+
+	if c := f.Cursor; c != nil {
+		n := s.param(&args, c.BlockNumber)
+		query.WriteString("AND (t1.block_number < " + n + " OR (t1.block_number = " + s.param(&args, c.BlockNumber) + " AND t1.tx_order < " + s.param(&args, c.Order) + "))\n")
+	}
+
+	query.WriteString("ORDER BY t1.block_number DESC, t1.tx_order DESC\nLIMIT " + s.param(&args, f.Limit))
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs = []*chain.Tx{}
+
+	for rows.Next() {
+		var t chain.Tx
+		if err := scanTx(rows, &t); err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
+
+// GetInternalTxs calls traced in a transaction, in trace order.
+func (s *Store) GetInternalTxs(ctx context.Context, hash string) ([]*chain.InternalTx, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectInternalTxs, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInternalTxs(rows)
+}
+
+// GetAddressInternalTxs calls from or to an address in range [i, j], newest first.
+func (s *Store) GetAddressInternalTxs(ctx context.Context, address string, i, j int64, limit int) ([]*chain.InternalTx, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectAddressInternalTxs, address, address, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInternalTxs(rows)
+}
+
+// scanInternalTxs
+func scanInternalTxs(rows *sql.Rows) ([]*chain.InternalTx, error) {
+	var txs = []*chain.InternalTx{}
+
+	for rows.Next() {
+		var t chain.InternalTx
+		if err := rows.Scan(
+			&t.TxHash,
+			&t.BlockNumber,
+			&t.Index,
+			&t.Depth,
+			&t.Type,
+			&t.From,
+			&t.To,
+			&t.Value,
+			&t.Error,
+		); err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, &t)
+	}
+
+	return txs, rows.Err()
+}
+
+// getReceipt returns the receipt of a transaction with its logs, nil if not indexed.
+func (s *Store) getReceipt(ctx context.Context, hash string) (*chain.Receipt, error) {
+	var r chain.Receipt
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectReceipt,
+		hash,
+	).Scan(
+		&r.TxHash,
+		&r.BlockNumber,
+		&r.Status,
+		&r.PostState,
+		&r.GasUsed,
+		&r.CumulativeGasUsed,
+		&r.EffectiveGasPrice,
+		&r.ContractAddress,
+		&r.BlobGasUsed,
+		&r.BlobGasPrice,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.q.SelectLogsByTx, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs, err := scanLogs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Logs = logs
+
+	return &r, nil
+}
+
+// GetLogs
+func (s *Store) GetLogs(ctx context.Context, f *chain.LogFilter) ([]*chain.Log, error) {
+	var (
+		query strings.Builder
+		args  = []any{f.FromBlock, f.ToBlock}
+	)
+
+	query.WriteString(s.q.SelectLogs)
+
+	if len(f.Addresses) > 0 {
+		query.WriteString("AND l1.address IN (" + s.params(&args, f.Addresses) + ")\n")
+	}
+
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+
+		query.WriteString(fmt.Sprintf("AND l1.topic%d IN (%s)\n", i, s.params(&args, topics)))
+	}
+
+	query.WriteString("ORDER BY l1.block_number ASC, l1.log_index ASC\nLIMIT " + s.param(&args, f.Limit))
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLogs(rows)
+}
+
+// nullString empty strings are stored as NULL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// param appends v to args, returns its placeholder.
+func (s *Store) param(args *[]any, v any) string {
+	*args = append(*args, v)
+	return s.q.Placeholder(len(*args))
+}
+
+// params appends vs to args, returns their comma separated placeholders.
+func (s *Store) params(args *[]any, vs []string) string {
+	var ps = make([]string, 0, len(vs))
+	for _, v := range vs {
+		ps = append(ps, s.param(args, v))
+	}
+	return strings.Join(ps, ",")
+}
+
+// scanLogs
+func scanLogs(rows *sql.Rows) ([]*chain.Log, error) {
+	var logs = []*chain.Log{}
+
+	for rows.Next() {
+		var (
+			l      chain.Log
+			topics [4]sql.NullString
+		)
+
+		if err := rows.Scan(
+			&l.TxHash,
+			&l.BlockNumber,
+			&l.Index,
+			&l.Address,
+			&topics[0],
+			&topics[1],
+			&topics[2],
+			&topics[3],
+			&l.Data,
+		); err != nil {
+			return nil, err
+		}
+
+		l.Topics = []string{}
+		for _, t := range topics {
+			if !t.Valid {
+				break
+			}
+			l.Topics = append(l.Topics, t.String)
+		}
+
+		logs = append(logs, &l)
+	}
+
+	return logs, rows.Err()
+}
+
+// GetToken
+func (s *Store) GetToken(ctx context.Context, address string) (*chain.Token, error) {
+	var t chain.Token
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectToken,
+		address,
+	).Scan(
+		&t.Address,
+		&t.Name,
+		&t.Symbol,
+		&t.Decimals,
+	); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetTokenTransfers transfers of a token in range [i, j], newest first.
+func (s *Store) GetTokenTransfers(ctx context.Context, token string, i, j int64, limit int) ([]*chain.TokenTransfer, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectTokenTransfers, token, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTokenTransfers(rows)
+}
+
+// GetAddressTokenTransfers transfers from or to an address in range [i, j], newest first.
+func (s *Store) GetAddressTokenTransfers(ctx context.Context, address string, i, j int64, limit int) ([]*chain.TokenTransfer, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectAddressTokenTransfers, address, address, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTokenTransfers(rows)
+}
+
+// scanTokenTransfers
+func scanTokenTransfers(rows *sql.Rows) ([]*chain.TokenTransfer, error) {
+	var transfers = []*chain.TokenTransfer{}
+
+	for rows.Next() {
+		var t chain.TokenTransfer
+		if err := rows.Scan(
+			&t.TxHash,
+			&t.BlockNumber,
+			&t.LogIndex,
+			&t.Token,
+			&t.From,
+			&t.To,
+			&t.Amount,
+		); err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, &t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// GetNFTTransfers newest first.
+func (s *Store) GetNFTTransfers(ctx context.Context, f *chain.NFTTransferFilter) ([]*chain.NFTTransfer, error) {
+	var (
+		query strings.Builder
+		args  = []any{f.FromBlock, f.ToBlock}
+	)
+
+	query.WriteString(s.q.SelectNFTTransfers)
+
+	if f.Collection != "" {
+		query.WriteString("AND n1.collection = " + s.param(&args, f.Collection) + "\n")
+	}
+
+	if f.TokenID != "" {
+		query.WriteString("AND n1.token_id = " + s.param(&args, f.TokenID) + "\n")
+	}
+
+	if f.Address != "" {
+		n := s.param(&args, f.Address)
+		query.WriteString("AND (n1.transfer_from = " + n + " OR n1.transfer_to = " + s.param(&args, f.Address) + ")\n")
+	}
+
+	query.WriteString("ORDER BY n1.block_number DESC, n1.log_index DESC, n1.batch_index DESC\nLIMIT " + s.param(&args, f.Limit))
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers = []*chain.NFTTransfer{}
+
+	for rows.Next() {
+		var t chain.NFTTransfer
+		if err := rows.Scan(
+			&t.TxHash,
+			&t.BlockNumber,
+			&t.LogIndex,
+			&t.BatchIndex,
+			&t.Standard,
+			&t.Collection,
+			&t.Operator,
+			&t.From,
+			&t.To,
+			&t.TokenID,
+			&t.Quantity,
+		); err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, &t)
+	}
+
+	return transfers, rows.Err()
+}
+
+// GetNFTOwners replays the transfers of a token to derive its current holders.
+func (s *Store) GetNFTOwners(ctx context.Context, collection, tokenID string) ([]*chain.NFTOwner, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectNFTTokenTransfers, collection, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ledger = utils.NewLedger()
+
+	for rows.Next() {
+		var from, to, quantity string
+		if err := rows.Scan(&from, &to, &quantity); err != nil {
+			return nil, err
+		}
+
+		ledger.Transfer(from, to, quantity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var owners = []*chain.NFTOwner{}
+	for _, h := range ledger.Holders() {
+		owners = append(owners, &chain.NFTOwner{
+			Address:  h.Address,
+			Quantity: h.Balance.String(),
+		})
+	}
+
+	return owners, nil
+}
+
+// GetBalance sums the balance deltas of an address up to block n, every
+// delta of the address is read so the cost grows with its activity.
+// Running balances can't be kept instead, blocks are saved out of order
+// by the workers & deleted on reorgs.
+func (s *Store) GetBalance(ctx context.Context, address string, n int64) (*chain.Balance, error) {
+	balance, err := s.sum(ctx, s.q.SelectBalance, address, n)
+	if err != nil {
+		return nil, fmt.Errorf("address %s: %w", address, err)
+	}
+
+	return &chain.Balance{
+		Address:     address,
+		BlockNumber: n,
+		Balance:     balance.String(),
+	}, nil
+}
+
+// sum the decimal values returned by query, either one row per value
+// where the database arithmetic overflows 64 bits, e.g. SQLite, or a
+// single row summed by the database.
+func (s *Store) sum(ctx context.Context, query string, args ...any) (*big.Int, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var total = new(big.Int)
+
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+
+		value, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+
+		total.Add(total, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return total, nil
+}
+
+// GetBalanceAddresses a random sample of addresses
+// which balance changed in range [i, j].
+func (s *Store) GetBalanceAddresses(ctx context.Context, i, j int64, limit int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectBalanceAddresses, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses = []string{}
+
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+// GetContract the latest deployment at address.
+func (s *Store) GetContract(ctx context.Context, address string) (*chain.Contract, error) {
+	var c chain.Contract
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectContract,
+		address,
+	).Scan(
+		&c.Address,
+		&c.Deployer,
+		&c.TxHash,
+		&c.BlockNumber,
+		&c.CodeHash,
+	); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// GetDeployerContracts contracts deployed by an address in range [i, j], newest first.
+func (s *Store) GetDeployerContracts(ctx context.Context, deployer string, i, j int64, limit int) ([]*chain.Contract, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectDeployerContracts, deployer, i, j, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contracts = []*chain.Contract{}
+
+	for rows.Next() {
+		var c chain.Contract
+		if err := rows.Scan(
+			&c.Address,
+			&c.Deployer,
+			&c.TxHash,
+			&c.BlockNumber,
+			&c.CodeHash,
+		); err != nil {
+			return nil, err
+		}
+
+		contracts = append(contracts, &c)
+	}
+
+	return contracts, rows.Err()
+}
+
+// GetRangeStats
+func (s *Store) GetStats(ctx context.Context, i, j int64) (*chain.Stats, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectTxHashes, i, j)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var status = &chain.Stats{
+		Txs: []string{},
+	}
+
+	for rows.Next() {
+		var tx string
+		if err := rows.Scan(&tx); err != nil {
+			return nil, err
+		}
+
+		status.Txs = append(status.Txs, tx)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	total, err := s.sum(ctx, s.q.SelectTxAmounts, i, j)
+	if err != nil {
+		return nil, err
+	}
+
+	status.TotalAmount = total.String()
+
+	return status, nil
+}
+
+// GetGaps returns ranges of missing blocks in range [i, j]
+func (s *Store) GetGaps(ctx context.Context, i, j int64) ([]*chain.Gap, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectGaps, i, i, j, j)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gaps = []*chain.Gap{}
+
+	for rows.Next() {
+		var g chain.Gap
+		if err := rows.Scan(&g.From, &g.To); err != nil {
+			return nil, err
+		}
+
+		gaps = append(gaps, &g)
+	}
+
+	return gaps, rows.Err()
+}
+
+// Indexer service \\
+
+// HasScanned
+func (s *Store) HasScanned(ctx context.Context, id int64) bool {
+	var found bool
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.HasScanned,
+		id,
+	).Scan(&found); err != nil {
+		return false
+	}
+
+	return found
+}
+
+// saveNFTTransfer
+func (s *Store) saveNFTTransfer(ctx context.Context, e execer, t *chain.NFTTransfer) error {
+	_, err := e.ExecContext(
+		ctx,
+		s.q.InsertNFTTransfer,
+		t.BlockNumber,
+		t.LogIndex,
+		t.BatchIndex,
+		t.TxHash,
+		t.Standard,
+		t.Collection,
+		t.Operator,
+		t.From,
+		t.To,
+		t.TokenID,
+		t.Quantity,
+	)
+	return err
+}
+
+// saveInternalTxs
+func (s *Store) saveInternalTxs(ctx context.Context, e execer, txs []*chain.InternalTx) error {
+	for _, t := range txs {
+		if _, err := e.ExecContext(
+			ctx,
+			s.q.InsertInternalTx,
+			t.TxHash,
+			t.Index,
+			t.BlockNumber,
+			t.Depth,
+			t.Type,
+			t.From,
+			t.To,
+			t.Value,
+			nullString(t.Error),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveReceipt
+func (s *Store) saveReceipt(ctx context.Context, e execer, r *chain.Receipt) error {
+	if _, err := e.ExecContext(
+		ctx,
+		s.q.InsertReceipt,
+		r.TxHash,
+		r.BlockNumber,
+		r.Status,
+		nullString(r.PostState),
+		r.GasUsed,
+		r.CumulativeGasUsed,
+		r.EffectiveGasPrice,
+		nullString(r.ContractAddress),
+		r.BlobGasUsed,
+		nullString(r.BlobGasPrice),
+	); err != nil {
+		return err
+	}
+
+	for _, l := range r.Logs {
+		var topics [4]sql.NullString
+		for i := 0; i < len(l.Topics) && i < len(topics); i++ {
+			topics[i] = sql.NullString{String: l.Topics[i], Valid: true}
+		}
+
+		if _, err := e.ExecContext(
+			ctx,
+			s.q.InsertLog,
+			l.BlockNumber,
+			l.Index,
+			l.TxHash,
+			l.Address,
+			topics[0],
+			topics[1],
+			topics[2],
+			topics[3],
+			l.Data,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveBalanceDeltas
+func (s *Store) saveBalanceDeltas(ctx context.Context, e execer, deltas []*chain.BalanceDelta) error {
+	for _, d := range deltas {
+		if _, err := e.ExecContext(
+			ctx,
+			s.q.InsertBalanceDelta,
+			d.BlockNumber,
+			d.Address,
+			d.Delta,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveContract
+func (s *Store) saveContract(ctx context.Context, e execer, c *chain.Contract) error {
+	_, err := e.ExecContext(
+		ctx,
+		s.q.InsertContract,
+		c.Address,
+		c.Deployer,
+		c.TxHash,
+		c.BlockNumber,
+		nullString(c.CodeHash),
+	)
+	return err
+}
+
+// HasToken
+func (s *Store) HasToken(ctx context.Context, address string) bool {
+	var found bool
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.HasToken,
+		address,
+	).Scan(&found); err != nil {
+		return false
+	}
+
+	return found
+}
+
+// saveToken unless already saved, e.g. by another writer.
+func (s *Store) saveToken(ctx context.Context, e execer, t *chain.Token) error {
+	_, err := e.ExecContext(
+		ctx,
+		s.q.InsertToken,
+		t.Address,
+		t.Name,
+		t.Symbol,
+		t.Decimals,
+	)
+	return err
+}
+
+// saveTokenTransfer
+func (s *Store) saveTokenTransfer(ctx context.Context, e execer, t *chain.TokenTransfer) error {
+	_, err := e.ExecContext(
+		ctx,
+		s.q.InsertTokenTransfer,
+		t.BlockNumber,
+		t.LogIndex,
+		t.TxHash,
+		t.Token,
+		t.From,
+		t.To,
+		t.Amount,
+	)
+	return err
+}
+
+// GetBlockHash
+func (s *Store) GetBlockHash(ctx context.Context, id int64) (string, error) {
+	var hash string
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectBlockHash,
+		id,
+	).Scan(&hash); err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+// GetTotalDifficulty of block id, empty when unknown.
+func (s *Store) GetTotalDifficulty(ctx context.Context, id int64) (string, error) {
+	var td string
+	if err := s.db.QueryRowContext(
+		ctx,
+		s.q.SelectTotalDifficulty,
+		id,
+	).Scan(&td); err != nil {
+		return "", err
+	}
+
+	return td, nil
+}
+
+// SetTotalDifficulty of block id, e.g. once its parent's is known.
+func (s *Store) SetTotalDifficulty(ctx context.Context, id int64, td string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		s.q.UpdateTotalDifficulty,
+		td,
+		id,
+	)
+	return err
+}
+
+// DeleteBlocks deletes blocks in range [i, j] along with their transactions.
+func (s *Store) DeleteBlocks(ctx context.Context, i, j int64) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		s.q.DeleteBlocks,
+		i,
+		j,
+	)
+	return err
+}
+
+// saveBlock
+func (s *Store) saveBlock(ctx context.Context, e execer, b *chain.Block) error {
+	if _, err := e.ExecContext(
+		ctx,
+		s.q.InsertBlock,
+		b.Number,
+		b.Hash,
+		b.ParentHash,
+		b.Miner,
+		b.Timestamp,
+		b.GasLimit,
+		b.GasUsed,
+		nullString(b.BaseFee),
+		b.Difficulty,
+		nullString(b.TotalDifficulty),
+		b.ExtraData,
+		b.Size,
+		b.StateRoot,
+		nullString(b.WithdrawalsRoot),
+		b.TxCount,
+	); err != nil {
+		return err
+	}
+
+	for _, w := range b.Withdrawals {
+		if _, err := e.ExecContext(
+			ctx,
+			s.q.InsertWithdrawal,
+			w.Index,
+			w.BlockNumber,
+			w.ValidatorIndex,
+			w.Address,
+			w.Amount,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveTx
+func (s *Store) saveTx(ctx context.Context, e execer, tx *chain.Tx) error {
+	if _, err := e.ExecContext(
+		ctx,
+		s.q.InsertTx,
+		tx.Hash,
+		tx.BlockNumber,
+		tx.From,
+		nullString(tx.To),
+		tx.Amount,
+		tx.Nonce,
+		tx.Timestamp,
+		tx.Order,
+		tx.Type,
+		tx.GasLimit,
+		tx.GasPrice,
+		nullString(tx.MaxFeePerGas),
+		nullString(tx.MaxPriorityFeePerGas),
+		nullString(tx.MaxFeePerBlobGas),
+		tx.Input,
+	); err != nil {
+		return err
+	}
+
+	for i, a := range tx.AccessList {
+		if _, err := e.ExecContext(ctx, s.q.InsertAccessList, tx.Hash, i, a.Address); err != nil {
+			return err
+		}
+
+		for j, key := range a.StorageKeys {
+			if _, err := e.ExecContext(ctx, s.q.InsertAccessListKey, tx.Hash, i, j, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, h := range tx.BlobHashes {
+		if _, err := e.ExecContext(ctx, s.q.InsertBlobHash, tx.Hash, i, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetScanRanges
+func (s *Store) GetScanRanges(ctx context.Context) ([]*chain.ScanRange, error) {
+	rows, err := s.db.QueryContext(ctx, s.q.SelectScanRanges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges = []*chain.ScanRange{}
+
+	for rows.Next() {
+		var r chain.ScanRange
+		if err := rows.Scan(
+			&r.ID,
+			&r.Start,
+			&r.End,
+			&r.Cursor,
+			&r.Follow,
+			&r.Done,
+		); err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, &r)
+	}
+
+	return ranges, rows.Err()
+}
+
+// SaveScanRange
+func (s *Store) SaveScanRange(ctx context.Context, r *chain.ScanRange) error {
+	return s.db.QueryRowContext(
+		ctx,
+		s.q.InsertScanRange,
+		r.Start,
+		r.End,
+		r.Cursor,
+		r.Follow,
+		r.Done,
+	).Scan(&r.ID)
+}
+
+// UpdateScanRange
+func (s *Store) UpdateScanRange(ctx context.Context, r *chain.ScanRange) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		s.q.UpdateScanRange,
+		r.End,
+		r.Cursor,
+		r.Done,
+		r.ID,
+	)
+	return err
+}
+
+// // \\ \\
+// Close
+func (s *Store) Close() error {
+	return s.db.Close()
+}
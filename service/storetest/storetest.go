@@ -0,0 +1,761 @@
+// Package storetest a conformance suite for store backends, run from each
+// backend tests so they all behave the same for the indexer & rest services.
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twiny/blockscan/pkg/chain"
+)
+
+// Store the indexer `StoreWriter` & rest `StoreReader` methods.
+type Store interface {
+	Ping() error
+	//
+	HasScanned(ctx context.Context, id int64) bool
+	GetBlockHash(ctx context.Context, id int64) (string, error)
+	GetTotalDifficulty(ctx context.Context, id int64) (string, error)
+	SetTotalDifficulty(ctx context.Context, id int64, td string) error
+	DeleteBlocks(ctx context.Context, i, j int64) error
+	SaveBlockBatch(ctx context.Context, b *chain.BlockBatch) error
+	//
+	GetLatestBlock(ctx context.Context) (*chain.Block, error)
+	GetBlock(ctx context.Context, n int64) (*chain.Block, error)
+	GetLatestTx(ctx context.Context) (*chain.Tx, error)
+	GetTx(ctx context.Context, hash string) (*chain.Tx, error)
+	GetAddressTxs(ctx context.Context, f *chain.AddressTxFilter) ([]*chain.Tx, error)
+	GetInternalTxs(ctx context.Context, hash string) ([]*chain.InternalTx, error)
+	GetAddressInternalTxs(ctx context.Context, address string, i, j int64, limit int) ([]*chain.InternalTx, error)
+	GetAddressWithdrawals(ctx context.Context, address string, i, j int64, limit int) ([]*chain.Withdrawal, error)
+	GetLogs(ctx context.Context, f *chain.LogFilter) ([]*chain.Log, error)
+	//
+	GetBalance(ctx context.Context, address string, n int64) (*chain.Balance, error)
+	GetBalanceAddresses(ctx context.Context, i, j int64, limit int) ([]string, error)
+	//
+	HasToken(ctx context.Context, address string) bool
+	GetToken(ctx context.Context, address string) (*chain.Token, error)
+	GetTokenTransfers(ctx context.Context, token string, i, j int64, limit int) ([]*chain.TokenTransfer, error)
+	GetAddressTokenTransfers(ctx context.Context, address string, i, j int64, limit int) ([]*chain.TokenTransfer, error)
+	GetNFTTransfers(ctx context.Context, f *chain.NFTTransferFilter) ([]*chain.NFTTransfer, error)
+	GetNFTOwners(ctx context.Context, collection, tokenID string) ([]*chain.NFTOwner, error)
+	//
+	GetContract(ctx context.Context, address string) (*chain.Contract, error)
+	GetDeployerContracts(ctx context.Context, deployer string, i, j int64, limit int) ([]*chain.Contract, error)
+	//
+	GetStats(ctx context.Context, i, j int64) (*chain.Stats, error)
+	GetGaps(ctx context.Context, i, j int64) ([]*chain.Gap, error)
+	//
+	GetScanRanges(ctx context.Context) ([]*chain.ScanRange, error)
+	SaveScanRange(ctx context.Context, r *chain.ScanRange) error
+	UpdateScanRange(ctx context.Context, r *chain.ScanRange) error
+}
+
+// Run runs the suite, newStore returns an empty store for each test.
+func Run(t *testing.T, newStore func(t *testing.T) Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s Store)
+	}{
+		{"TestBlockRoundTrip", testBlockRoundTrip},
+		{"TestTxRoundTrip", testTxRoundTrip},
+		{"TestHasScanned", testHasScanned},
+		{"TestLatest", testLatest},
+		{"TestStatsRange", testStatsRange},
+		{"TestDuplicateBlock", testDuplicateBlock},
+		{"TestDuplicateTx", testDuplicateTx},
+		{"TestNotFound", testNotFound},
+		{"TestDeleteBlocks", testDeleteBlocks},
+		{"TestContractRedeploy", testContractRedeploy},
+		{"TestBlockTokens", testBlockTokens},
+		{"TestGaps", testGaps},
+		{"TestBalance", testBalance},
+		{"TestScanRanges", testScanRanges},
+		{"TestConcurrentWriters", testConcurrentWriters},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newStore(t))
+		})
+	}
+}
+
+// timestamp of block n, second precision as stored.
+func timestamp(n int64) time.Time {
+	return time.Unix(1700000000+n*12, 0).UTC()
+}
+
+// hash a 32 bytes hex hash ending with n.
+func hash(prefix string, n int64) string {
+	return fmt.Sprintf("0x%s%062x", prefix, n)
+}
+
+// newBatch block n with one transfer tx per amount, from 0xa to 0xb.
+func newBatch(n int64, amounts ...string) *chain.BlockBatch {
+	bb := &chain.BlockBatch{
+		Block: &chain.Block{
+			Number:     n,
+			Hash:       hash("b0", n),
+			ParentHash: hash("b0", n-1),
+			Miner:      "0xm",
+			Timestamp:  timestamp(n),
+			Difficulty: "0",
+			ExtraData:  "0x",
+			TxCount:    uint(len(amounts)),
+		},
+	}
+
+	for i, amount := range amounts {
+		bb.Txs = append(bb.Txs, &chain.Tx{
+			Hash:        hash(fmt.Sprintf("%02x", i), n),
+			BlockNumber: n,
+			From:        "0xa",
+			To:          "0xb",
+			Amount:      amount,
+			Timestamp:   timestamp(n),
+			Order:       i,
+			GasPrice:    "1",
+			Input:       "0x",
+			Receipt: &chain.Receipt{
+				TxHash:            hash(fmt.Sprintf("%02x", i), n),
+				BlockNumber:       n,
+				Status:            1,
+				GasUsed:           21000,
+				CumulativeGasUsed: 21000 * uint64(i+1),
+				EffectiveGasPrice: "1",
+				Logs:              []*chain.Log{},
+			},
+		})
+	}
+
+	return bb
+}
+
+// save
+func save(t *testing.T, s Store, batches ...*chain.BlockBatch) {
+	t.Helper()
+
+	for _, bb := range batches {
+		if err := s.SaveBlockBatch(context.Background(), bb); err != nil {
+			t.Fatalf("block %d: %v", bb.Block.Number, err)
+		}
+	}
+}
+
+// testBlockRoundTrip
+func testBlockRoundTrip(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	bb := newBatch(7, "1", "2")
+	bb.Block.GasLimit = 30000000
+	bb.Block.GasUsed = 42000
+	bb.Block.BaseFee = "1000000000"
+	bb.Block.TotalDifficulty = "58750003716598352816469"
+	bb.Block.Size = 1024
+	bb.Block.StateRoot = hash("5e", 7)
+	bb.Block.WithdrawalsRoot = hash("77", 7)
+	bb.Block.Withdrawals = []*chain.Withdrawal{
+		{Index: 70, BlockNumber: 7, ValidatorIndex: 1, Address: "0xv", Amount: 32000000000},
+	}
+
+	save(t, s, bb)
+
+	got, err := s.GetBlock(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Timestamp.Equal(bb.Block.Timestamp) {
+		t.Errorf("got timestamp %s, want %s", got.Timestamp, bb.Block.Timestamp)
+	}
+
+	want := *bb.Block
+	want.Txs = []string{bb.Txs[0].Hash, bb.Txs[1].Hash}
+	want.Timestamp, got.Timestamp = time.Time{}, time.Time{}
+
+	if !reflect.DeepEqual(got, &want) {
+		t.Errorf("got block %+v, want %+v", got, &want)
+	}
+
+	h, err := s.GetBlockHash(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h != bb.Block.Hash {
+		t.Errorf("got hash %s, want %s", h, bb.Block.Hash)
+	}
+
+	td, err := s.GetTotalDifficulty(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if td != bb.Block.TotalDifficulty {
+		t.Errorf("got total difficulty %s, want %s", td, bb.Block.TotalDifficulty)
+	}
+
+	// saved before its parent, filled in later
+	save(t, s, newBatch(9))
+
+	if td, err := s.GetTotalDifficulty(ctx, 9); err != nil || td != "" {
+		t.Errorf("got total difficulty %q, %v, want empty", td, err)
+	}
+
+	if err := s.SetTotalDifficulty(ctx, 9, "58750003716598352816471"); err != nil {
+		t.Fatal(err)
+	}
+
+	if td, err := s.GetTotalDifficulty(ctx, 9); err != nil || td != "58750003716598352816471" {
+		t.Errorf("got total difficulty %q, %v, want 58750003716598352816471", td, err)
+	}
+
+	withdrawals, err := s.GetAddressWithdrawals(ctx, "0xv", 0, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(withdrawals) != 1 || !reflect.DeepEqual(withdrawals[0], bb.Block.Withdrawals[0]) {
+		t.Errorf("got withdrawals %+v", withdrawals)
+	}
+}
+
+// testTxRoundTrip
+func testTxRoundTrip(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	bb := newBatch(3)
+	tx := &chain.Tx{
+		Hash:                 hash("77", 3),
+		BlockNumber:          3,
+		From:                 "0xa",
+		Amount:               "100000000000000000000000", // over 64 bits
+		Nonce:                9,
+		Timestamp:            timestamp(3),
+		Order:                0,
+		Type:                 3,
+		GasLimit:             100000,
+		GasPrice:             "3",
+		MaxFeePerGas:         "4",
+		MaxPriorityFeePerGas: "1",
+		MaxFeePerBlobGas:     "2",
+		Input:                "0x60806040",
+		AccessList: []*chain.AccessTuple{
+			{Address: "0xc", StorageKeys: []string{hash("01", 0), hash("02", 0)}},
+			{Address: "0xd", StorageKeys: []string{}},
+		},
+		BlobHashes: []string{hash("01", 1)},
+		Receipt: &chain.Receipt{
+			TxHash:            hash("77", 3),
+			BlockNumber:       3,
+			Status:            1,
+			PostState:         hash("5e", 3), // pre-Byzantium only, round tripped here
+			GasUsed:           50000,
+			CumulativeGasUsed: 50000,
+			EffectiveGasPrice: "3",
+			ContractAddress:   "0xc",
+			BlobGasUsed:       131072,
+			BlobGasPrice:      "100000000000000000000000", // over 64 bits
+			Logs: []*chain.Log{
+				{TxHash: hash("77", 3), BlockNumber: 3, Index: 0, Address: "0xc", Topics: []string{hash("70", 0)}, Data: "0x01"},
+				{TxHash: hash("77", 3), BlockNumber: 3, Index: 1, Address: "0xc", Topics: []string{}, Data: "0x"},
+			},
+		},
+	}
+
+	bb.Txs = []*chain.Tx{tx}
+	bb.Block.TxCount = 1
+	bb.InternalTxs = []*chain.InternalTx{
+		{TxHash: tx.Hash, BlockNumber: 3, Index: 0, Depth: 1, Type: "CALL", From: "0xc", To: "0xe", Value: "5"},
+		{TxHash: tx.Hash, BlockNumber: 3, Index: 1, Depth: 2, Type: "STATICCALL", From: "0xe", To: "0xf", Value: "0", Error: "execution reverted"},
+	}
+	bb.Contracts = []*chain.Contract{
+		{Address: "0xc", Deployer: "0xa", TxHash: tx.Hash, BlockNumber: 3, CodeHash: hash("c0", 0)},
+	}
+
+	save(t, s, bb)
+
+	got, err := s.GetTx(ctx, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.Timestamp.Equal(tx.Timestamp) {
+		t.Errorf("got timestamp %s, want %s", got.Timestamp, tx.Timestamp)
+	}
+
+	want := *tx
+	want.Timestamp, got.Timestamp = time.Time{}, time.Time{}
+
+	if !reflect.DeepEqual(got, &want) {
+		t.Errorf("got tx %+v, want %+v", got, &want)
+	}
+
+	internal, err := s.GetInternalTxs(ctx, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(internal, bb.InternalTxs) {
+		t.Errorf("got internal txs %+v, want %+v", internal, bb.InternalTxs)
+	}
+
+	contract, err := s.GetContract(ctx, "0xc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(contract, bb.Contracts[0]) {
+		t.Errorf("got contract %+v, want %+v", contract, bb.Contracts[0])
+	}
+
+	logs, err := s.GetLogs(ctx, &chain.LogFilter{FromBlock: 3, ToBlock: 3, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(logs, tx.Receipt.Logs) {
+		t.Errorf("got logs %+v, want %+v", logs, tx.Receipt.Logs)
+	}
+}
+
+// testHasScanned
+func testHasScanned(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	if s.HasScanned(ctx, 1) {
+		t.Error("empty store, block 1 scanned")
+	}
+
+	save(t, s, newBatch(1))
+
+	if !s.HasScanned(ctx, 1) {
+		t.Error("block 1 not scanned")
+	}
+
+	if s.HasScanned(ctx, 2) {
+		t.Error("block 2 scanned")
+	}
+}
+
+// testLatest the latest block is the latest mined, whatever the save order.
+func testLatest(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	save(t, s, newBatch(3, "1", "2", "3"), newBatch(1, "1"), newBatch(2, "1"))
+
+	block, err := s.GetLatestBlock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if block.Number != 3 {
+		t.Errorf("got latest block %d, want 3", block.Number)
+	}
+
+	tx, err := s.GetLatestTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.BlockNumber != 3 || tx.Order != 2 {
+		t.Errorf("got latest tx %d:%d, want 3:2", tx.BlockNumber, tx.Order)
+	}
+}
+
+// testStatsRange ranges are inclusive.
+func testStatsRange(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	save(t, s,
+		newBatch(1, "1"),
+		newBatch(2, "10", "100"),
+		newBatch(3, "1000"),
+		newBatch(4, "100000000000000000000000"),
+	)
+
+	tests := []struct {
+		name  string
+		i, j  int64
+		txs   int
+		total string
+	}{
+		{"TestInner", 2, 3, 3, "1110"},
+		{"TestSingle", 2, 2, 2, "110"},
+		{"TestAll", 0, 10, 5, "100000000000000000001111"},
+		{"TestEmpty", 5, 9, 0, "0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stats, err := s.GetStats(ctx, tc.i, tc.j)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(stats.Txs) != tc.txs || stats.TotalAmount != tc.total {
+				t.Errorf("got %d txs, total %s, want %d, %s", len(stats.Txs), stats.TotalAmount, tc.txs, tc.total)
+			}
+		})
+	}
+}
+
+// testDuplicateBlock saving a block twice fails, the first one is kept.
+func testDuplicateBlock(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	save(t, s, newBatch(1, "1"))
+
+	dup := newBatch(1, "1")
+	dup.Block.Hash = hash("dd", 1)
+	dup.Txs[0].Hash = hash("dd", 1)
+
+	if err := s.SaveBlockBatch(ctx, dup); err == nil {
+		t.Fatal("expected error")
+	}
+
+	h, err := s.GetBlockHash(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h != hash("b0", 1) {
+		t.Errorf("got hash %s, want the first block", h)
+	}
+
+	if _, err := s.GetTx(ctx, hash("dd", 1)); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("got %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testDuplicateTx a batch with an existing tx is not saved at all.
+func testDuplicateTx(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	save(t, s, newBatch(1, "1"))
+
+	dup := newBatch(2, "1", "1")
+	dup.Txs[1] = newBatch(1, "1").Txs[0]
+
+	if err := s.SaveBlockBatch(ctx, dup); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if s.HasScanned(ctx, 2) {
+		t.Error("block 2 saved, want rolled back")
+	}
+
+	if _, err := s.GetTx(ctx, dup.Txs[0].Hash); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("got %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testNotFound single lookups return `sql.ErrNoRows`, lists are empty.
+func testNotFound(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	lookups := map[string]func() error{
+		"GetLatestBlock": func() error { _, err := s.GetLatestBlock(ctx); return err },
+		"GetLatestTx":    func() error { _, err := s.GetLatestTx(ctx); return err },
+		"GetBlock":       func() error { _, err := s.GetBlock(ctx, 1); return err },
+		"GetBlockHash":   func() error { _, err := s.GetBlockHash(ctx, 1); return err },
+		"GetTx":          func() error { _, err := s.GetTx(ctx, hash("00", 1)); return err },
+		"GetToken":       func() error { _, err := s.GetToken(ctx, "0xt"); return err },
+		"GetContract":    func() error { _, err := s.GetContract(ctx, "0xc"); return err },
+	}
+
+	for name, lookup := range lookups {
+		if err := lookup(); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s: got %v, want sql.ErrNoRows", name, err)
+		}
+	}
+
+	if s.HasToken(ctx, "0xt") {
+		t.Error("HasToken: got true")
+	}
+
+	internal, err := s.GetInternalTxs(ctx, hash("00", 1))
+	if err != nil || internal == nil || len(internal) != 0 {
+		t.Errorf("GetInternalTxs: got %v, %v, want empty", internal, err)
+	}
+
+	txs, err := s.GetAddressTxs(ctx, &chain.AddressTxFilter{Address: "0xa", FromBlock: 0, ToBlock: 10, Limit: 10})
+	if err != nil || txs == nil || len(txs) != 0 {
+		t.Errorf("GetAddressTxs: got %v, %v, want empty", txs, err)
+	}
+
+	balance, err := s.GetBalance(ctx, "0xa", 10)
+	if err != nil || balance.Balance != "0" {
+		t.Errorf("GetBalance: got %v, %v, want 0", balance, err)
+	}
+}
+
+// testDeleteBlocks blocks are deleted along with their txs.
+func testDeleteBlocks(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	save(t, s, newBatch(1, "1"), newBatch(2, "1"), newBatch(3, "1"))
+
+	if err := s.DeleteBlocks(ctx, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	gaps, err := s.GetGaps(ctx, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []*chain.Gap{{From: 2, To: 4}}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("got gaps %+v, want %+v", gaps, want)
+	}
+
+	if _, err := s.GetTx(ctx, newBatch(2, "1").Txs[0].Hash); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("got %v, want sql.ErrNoRows", err)
+	}
+
+	// deleted blocks can be saved again
+	save(t, s, newBatch(2, "1"))
+}
+
+// testContractRedeploy an address deployed again after a self-destruct,
+// e.g. with CREATE2, keeps both deployments.
+func testContractRedeploy(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	var deployments = []*chain.Contract{}
+
+	for _, n := range []int64{3, 5} {
+		bb := newBatch(n, "0")
+		bb.Contracts = []*chain.Contract{
+			{Address: "0xc", Deployer: "0xa", TxHash: bb.Txs[0].Hash, BlockNumber: n, CodeHash: hash("c0", n)},
+		}
+
+		save(t, s, bb)
+
+		deployments = append(deployments, bb.Contracts[0])
+	}
+
+	contract, err := s.GetContract(ctx, "0xc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(contract, deployments[1]) {
+		t.Errorf("got contract %+v, want the latest deployment %+v", contract, deployments[1])
+	}
+
+	contracts, err := s.GetDeployerContracts(ctx, "0xa", 0, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(contracts, []*chain.Contract{deployments[1], deployments[0]}) {
+		t.Errorf("got contracts %+v, want both deployments", contracts)
+	}
+
+	// rolled back
+	if err := s.DeleteBlocks(ctx, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	contract, err = s.GetContract(ctx, "0xc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(contract, deployments[0]) {
+		t.Errorf("got contract %+v, want the first deployment %+v", contract, deployments[0])
+	}
+}
+
+// testBlockTokens token metadata saved with a block, a token
+// already saved, e.g. by another writer, keeps its metadata.
+func testBlockTokens(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	first := &chain.Token{Address: "0xt", Name: "T", Symbol: "T", Decimals: 18}
+
+	bb := newBatch(3, "0")
+	bb.Tokens = []*chain.Token{first}
+	save(t, s, bb)
+
+	bb = newBatch(4, "0")
+	bb.Tokens = []*chain.Token{{Address: "0xt", Name: "U", Symbol: "U", Decimals: 6}}
+	save(t, s, bb)
+
+	if !s.HasToken(ctx, "0xt") {
+		t.Fatal("HasToken: got false")
+	}
+
+	token, err := s.GetToken(ctx, "0xt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(token, first) {
+		t.Errorf("got token %+v, want %+v", token, first)
+	}
+}
+
+// testGaps missing blocks of a range, merged into consecutive gaps.
+func testGaps(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		i, j int64
+		want []*chain.Gap
+	}{
+		{"TestEmpty", 0, 5, []*chain.Gap{{From: 0, To: 5}}},
+	}
+
+	// checked before any block is saved
+	for _, tc := range tests {
+		gaps, err := s.GetGaps(ctx, tc.i, tc.j)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(gaps, tc.want) {
+			t.Errorf("%s: got gaps %+v, want %+v", tc.name, gaps, tc.want)
+		}
+	}
+
+	save(t, s, newBatch(12), newBatch(13), newBatch(15), newBatch(18))
+
+	tests = []struct {
+		name string
+		i, j int64
+		want []*chain.Gap
+	}{
+		{"TestRange", 10, 20, []*chain.Gap{{From: 10, To: 11}, {From: 14, To: 14}, {From: 16, To: 17}, {From: 19, To: 20}}},
+		{"TestInner", 12, 18, []*chain.Gap{{From: 14, To: 14}, {From: 16, To: 17}}},
+		{"TestComplete", 12, 13, []*chain.Gap{}},
+		{"TestSingle", 14, 14, []*chain.Gap{{From: 14, To: 14}}},
+	}
+
+	for _, tc := range tests {
+		gaps, err := s.GetGaps(ctx, tc.i, tc.j)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(gaps) == 0 && len(tc.want) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(gaps, tc.want) {
+			t.Errorf("%s: got gaps %+v, want %+v", tc.name, gaps, tc.want)
+		}
+	}
+}
+
+// testBalance balances sum the deltas up to a block.
+func testBalance(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	for n, delta := range []string{"100000000000000000000000", "-1", "-99999999999999999999999"} {
+		bb := newBatch(int64(n + 1))
+		bb.BalanceDeltas = []*chain.BalanceDelta{{BlockNumber: int64(n + 1), Address: "0xa", Delta: delta}}
+		save(t, s, bb)
+	}
+
+	for n, want := range []string{"0", "100000000000000000000000", "99999999999999999999999", "0"} {
+		balance, err := s.GetBalance(ctx, "0xa", int64(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if balance.Balance != want {
+			t.Errorf("block %d: got balance %s, want %s", n, balance.Balance, want)
+		}
+	}
+
+	addresses, err := s.GetBalanceAddresses(ctx, 1, 3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(addresses, []string{"0xa"}) {
+		t.Errorf("got addresses %v, want [0xa]", addresses)
+	}
+}
+
+// testScanRanges
+func testScanRanges(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	first := &chain.ScanRange{Start: 1, End: 10, Cursor: 1}
+	second := &chain.ScanRange{Start: 20, End: 20, Cursor: 20, Follow: true}
+
+	for _, r := range []*chain.ScanRange{first, second} {
+		if err := s.SaveScanRange(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if first.ID == 0 || second.ID <= first.ID {
+		t.Fatalf("got ids %d, %d, want increasing", first.ID, second.ID)
+	}
+
+	first.Cursor, first.Done = 11, true
+	if err := s.UpdateScanRange(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+
+	ranges, err := s.GetScanRanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []*chain.ScanRange{first, second}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("got ranges %+v, want %+v", ranges, want)
+	}
+}
+
+// testConcurrentWriters blocks saved by concurrent workers are all kept.
+func testConcurrentWriters(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	const workers, blocks = 4, 40
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			for n := int64(w); n < blocks; n += workers {
+				if err := s.SaveBlockBatch(ctx, newBatch(n, "1", "2")); err != nil {
+					t.Errorf("block %d: %v", n, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	gaps, err := s.GetGaps(ctx, 0, blocks-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gaps) != 0 {
+		t.Errorf("got gaps %+v", gaps)
+	}
+
+	stats, err := s.GetStats(ctx, 0, blocks-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats.Txs) != 2*blocks || stats.TotalAmount != fmt.Sprint(3*blocks) {
+		t.Errorf("got %d txs, total %s", len(stats.Txs), stats.TotalAmount)
+	}
+}